@@ -16,6 +16,13 @@ var Debug DebugFlags
 // The -d option takes a comma-separated list of settings.
 // Each setting is name=value; for ints, name is short for name=1.
 type DebugFlags struct {
+	Alg                   int    `help:"print information about equality and hash algorithm selection"`
+	AlgCheck              int    `help:"check that struct layouts are complete before selecting their equality and hash algorithms" concurrent:"ok"`
+	AlgHash               int    `help:"force memory-comparable structs through generated hash and equality functions"`
+	AlgJSON               string `help:"write the equality and hash algorithm chosen for each named type to the specified file, as JSON"`
+	AlgNoMem              int    `help:"compare and hash memory-comparable structs and arrays component by component; unlike alghash, covers arrays and skips zero-size types"`
+	AlgNotes              int    `help:"report comparisons and map key types that need generated equality and hash functions"`
+	AlgPanic              int    `help:"warn about map key types whose interface components can make hashing panic"`
 	AlignHot              int    `help:"enable hot block alignment (currently requires -pgo)" concurrent:"ok"`
 	Append                int    `help:"print information about append compilation"`
 	Checkptr              int    `help:"instrument unsafe pointer conversions\n0: instrumentation disabled\n1: conversions involving unsafe.Pointer are instrumented\n2: conversions to unsafe.Pointer force heap allocation" concurrent:"ok"`
@@ -26,6 +26,10 @@ func IsRegularMemory(t *types.Type) bool {
 // size is the length in bytes of the memory included in the run.
 // next is the index just after the end of the memory run.
 func Memrun(t *types.Type, start int) (size int64, next int) {
+	if base.Debug.AlgNoMem != 0 {
+		// -d=algnomem compares and hashes fields one by one.
+		return t.Field(start).Type.Size(), start + 1
+	}
 	next = start
 	for {
 		next++
@@ -318,12 +322,8 @@ func EqInterface(s, t ir.Node) (eqtab *ir.BinaryExpr, eqdata *ir.CallExpr) {
 	}
 	// func ifaceeq(tab *uintptr, x, y unsafe.Pointer) (ret bool)
 	// func efaceeq(typ *uintptr, x, y unsafe.Pointer) (ret bool)
-	var fn ir.Node
-	if s.Type().IsEmptyInterface() {
-		fn = typecheck.LookupRuntime("efaceeq")
-	} else {
-		fn = typecheck.LookupRuntime("ifaceeq")
-	}
+	_, name := types.InterfaceCompareKind(s.Type())
+	fn := typecheck.LookupRuntime(name)
 
 	stab := ir.NewUnaryExpr(base.Pos, ir.OITAB, s)
 	ttab := ir.NewUnaryExpr(base.Pos, ir.OITAB, t)
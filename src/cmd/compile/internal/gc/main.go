@@ -173,6 +173,9 @@ func Main(archInit func(*ssagen.ArchInfo)) {
 	if base.Debug.SoftFloat != 0 {
 		ssagen.Arch.SoftFloat = true
 	}
+	if base.Debug.AlgHash != 0 || base.Debug.AlgNoMem != 0 {
+		types.AlgOverride[types.AMEM] = types.ASPECIAL
+	}
 
 	if base.Flag.JSON != "" { // parse version,destination from json logging optimization.
 		logopt.LogJsonOption(base.Flag.JSON)
@@ -345,6 +348,9 @@ func Main(archInit func(*ssagen.ArchInfo)) {
 	if base.Flag.AsmHdr != "" {
 		dumpasmhdr()
 	}
+	if base.Debug.AlgJSON != "" {
+		types.WriteAlgJSON(base.Debug.AlgJSON)
+	}
 
 	ssagen.CheckLargeStacks()
 	typecheck.CheckFuncStack()
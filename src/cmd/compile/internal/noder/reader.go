@@ -849,6 +849,9 @@ func (pr *pkgReader) objIdxMayFail(idx pkgbits.Index, implicits, explicits []*ty
 	case pkgbits.ObjVar:
 		name := do(ir.ONAME, false)
 		setType(name, r.typ())
+		if pr == localPkgReader && name.Type().IsMap() {
+			typecheck.NoteMapKeyAlg(name.Pos(), name.Type())
+		}
 		rext.varExt(name)
 		return name, nil
 	}
@@ -1782,6 +1785,9 @@ func (r *reader) assign() (ir.Node, bool) {
 		setBasePos(pos) // test/fixedbugs/issue49767.go depends on base.Pos being set for the r.typ() call here, ugh
 		name := r.curfn.NewLocal(pos, r.localIdent(), r.typ())
 		r.addLocal(name)
+		if r.p == localPkgReader && name.Type().IsMap() {
+			typecheck.NoteMapKeyAlg(pos, name.Type())
+		}
 		return name, true
 
 	case assignExpr:
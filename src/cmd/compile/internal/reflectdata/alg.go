@@ -18,7 +18,9 @@ import (
 )
 
 // AlgType returns the fixed-width AMEMxx variants instead of the general
-// AMEM kind when possible.
+// AMEM kind when possible. The variant depends only on the size of t, so
+// for example a struct of two bools uses AMEM16 just like an int16.
+// Variants wider than WidestMemAlg are not used.
 func AlgType(t *types.Type) types.AlgKind {
 	a := types.AlgType(t)
 	if a == types.AMEM {
@@ -27,60 +29,98 @@ func AlgType(t *types.Type) types.AlgKind {
 			// 4-byte alignment. See issue 46283.
 			return a
 		}
+		var v types.AlgKind
 		switch t.Size() {
 		case 0:
-			return types.AMEM0
+			v = types.AMEM0
 		case 1:
-			return types.AMEM8
+			v = types.AMEM8
 		case 2:
-			return types.AMEM16
+			v = types.AMEM16
 		case 4:
-			return types.AMEM32
+			v = types.AMEM32
 		case 8:
-			return types.AMEM64
+			v = types.AMEM64
 		case 16:
-			return types.AMEM128
+			v = types.AMEM128
+		case 32:
+			v = types.AMEM256
+		default:
+			return a
+		}
+		if v > WidestMemAlg() {
+			return a
+		}
+		return v
+	}
+	if a == types.ASPECIAL {
+		if v, ok := types.FloatVectorAlg(t); ok {
+			return v
 		}
 	}
 
 	return a
 }
 
+// WidestMemAlg returns the widest fixed-width AMEMxx variant that
+// AlgType uses on the target architecture. On 64-bit targets,
+// memequal128 and memequal256 compare two and four words. On 32-bit
+// targets, memequal128 still compares a pair of 64-bit halves, but
+// memequal256 would need eight word compares, so AlgType stops at
+// AMEM128 and wider memory-comparable types use the general AMEM kind,
+// which calls memequal with the size.
+func WidestMemAlg() types.AlgKind {
+	if types.RegSize < 8 {
+		return types.AMEM128
+	}
+	return types.AMEM256
+}
+
+// ConstStringCompareKind returns the AMEMxx kind for comparing the bytes
+// of a string against a constant string of the given length, once the
+// lengths are known to be equal. The bytes of a constant of length 0, 1,
+// 2, 4, or 8 can be compared with a single load of that width; for other
+// lengths ConstStringCompareKind returns ASTRING, the general string
+// comparison. walkCompareString makes the same kind of choice when it
+// rewrites comparisons against short constants into loads.
+func ConstStringCompareKind(length int64) types.AlgKind {
+	switch length {
+	case 0:
+		return types.AMEM0
+	case 1:
+		return types.AMEM8
+	case 2:
+		return types.AMEM16
+	case 4:
+		return types.AMEM32
+	case 8:
+		return types.AMEM64
+	}
+	return types.ASTRING
+}
+
+// KeyHashKind returns the AlgKind that selects the hash function for map
+// keys of type t. It is AlgType(t): the choice depends only on t's layout,
+// so pointer, unsafe.Pointer, and uintptr keys of the same width, and
+// structs wrapping them, all share one runtime hasher. The pointer
+// variants of the fast map routines, such as mapassign_fast64ptr, differ
+// only in how they store keys, not in how they hash them.
+func KeyHashKind(t *types.Type) types.AlgKind {
+	return AlgType(t)
+}
+
 // genhash returns a symbol which is the closure used to compute
 // the hash of a value of type t.
 // Note: the generated function must match runtime.typehash exactly.
 func genhash(t *types.Type) *obj.LSym {
-	switch AlgType(t) {
-	default:
-		// genhash is only called for types that have equality
-		base.Fatalf("genhash %v", t)
-	case types.AMEM0:
-		return sysClosure("memhash0")
-	case types.AMEM8:
-		return sysClosure("memhash8")
-	case types.AMEM16:
-		return sysClosure("memhash16")
-	case types.AMEM32:
-		return sysClosure("memhash32")
-	case types.AMEM64:
-		return sysClosure("memhash64")
-	case types.AMEM128:
-		return sysClosure("memhash128")
-	case types.ASTRING:
-		return sysClosure("strhash")
-	case types.AINTER:
-		return sysClosure("interhash")
-	case types.ANILINTER:
-		return sysClosure("nilinterhash")
-	case types.AFLOAT32:
-		return sysClosure("f32hash")
-	case types.AFLOAT64:
-		return sysClosure("f64hash")
-	case types.ACPLX64:
-		return sysClosure("c64hash")
-	case types.ACPLX128:
-		return sysClosure("c128hash")
-	case types.AMEM:
+	if !types.NeedsGeneratedHash(t) {
+		if name := runtimeHashName(t); name != "" {
+			return sysClosure(name)
+		}
+		if AlgType(t) != types.AMEM {
+			// genhash is only called for types that have equality
+			base.Fatalf("genhash %v", t)
+		}
 		// For other sizes of plain memory, we build a closure
 		// that calls memhash_varlen. The size of the memory is
 		// encoded in the first slot of the closure.
@@ -96,8 +136,6 @@ func genhash(t *types.Type) *obj.LSym {
 		ot = objw.Uintptr(closure, ot, uint64(t.Size())) // size encoded in closure
 		objw.Global(closure, int32(ot), obj.DUPOK|obj.RODATA)
 		return closure
-	case types.ASPECIAL:
-		break
 	}
 
 	closure := TypeLinksymPrefix(".hashfunc", t)
@@ -134,6 +172,50 @@ func genhash(t *types.Type) *obj.LSym {
 	return closure
 }
 
+// runtimeHashName returns the name of the runtime function that hashes
+// values of type t, or "" if there is none: t is plain memory of a size
+// without its own function, needs a generated hash function, or is not
+// hashable.
+func runtimeHashName(t *types.Type) string {
+	switch AlgType(t) {
+	case types.AMEM0:
+		return "memhash0"
+	case types.AMEM8:
+		return "memhash8"
+	case types.AMEM16:
+		return "memhash16"
+	case types.AMEM32:
+		return "memhash32"
+	case types.AMEM64:
+		return "memhash64"
+	case types.AMEM128:
+		return "memhash128"
+	case types.AMEM256:
+		return "memhash256"
+	case types.ASTRING:
+		return "strhash"
+	case types.AINTER:
+		return "interhash"
+	case types.ANILINTER:
+		return "nilinterhash"
+	case types.AFLOAT32:
+		return "f32hash"
+	case types.AFLOAT64:
+		return "f64hash"
+	case types.ACPLX64:
+		return "c64hash"
+	case types.ACPLX128:
+		return "c128hash"
+	case types.AFLOAT32x4:
+		return "f32x4hash"
+	case types.AFLOAT64x2:
+		return "f64x2hash"
+	case types.AFLOAT64x4:
+		return "f64x4hash"
+	}
+	return ""
+}
+
 func hashFunc(t *types.Type) *ir.Func {
 	sym := TypeSymPrefix(".hash", t)
 	if sym.Def != nil {
@@ -162,11 +244,6 @@ func hashFunc(t *types.Type) *ir.Func {
 
 	switch t.Kind() {
 	case types.TARRAY:
-		// An array of pure memory would be handled by the
-		// standard algorithm, so the element type must not be
-		// pure memory.
-		hashel := hashfor(t.Elem())
-
 		// for i := 0; i < nelem; i++
 		ni := typecheck.TempAt(base.Pos, ir.CurFunc, types.Types[types.TINT])
 		init := ir.NewAssignStmt(base.Pos, ni, ir.NewInt(base.Pos, 0))
@@ -176,13 +253,9 @@ func hashFunc(t *types.Type) *ir.Func {
 		loop.PtrInit().Append(init)
 
 		// h = hashel(&p[i], h)
-		call := ir.NewCallExpr(base.Pos, ir.OCALL, hashel, nil)
-
 		nx := ir.NewIndexExpr(base.Pos, np, ni)
 		nx.SetBounded(true)
-		na := typecheck.NodAddr(nx)
-		call.Args.Append(na)
-		call.Args.Append(nh)
+		call := hashCall(t.Elem(), typecheck.NodAddr(nx), nh)
 		loop.Body.Append(ir.NewAssignStmt(base.Pos, nh, call))
 
 		fn.Body.Append(loop)
@@ -200,12 +273,10 @@ func hashFunc(t *types.Type) *ir.Func {
 			}
 
 			// Hash non-memory fields with appropriate hash function.
-			if !compare.IsRegularMemory(f.Type) {
-				hashel := hashfor(f.Type)
-				call := ir.NewCallExpr(base.Pos, ir.OCALL, hashel, nil)
+			// Under -d=algnomem, so are memory fields, one by one.
+			if !compare.IsRegularMemory(f.Type) || base.Debug.AlgNoMem != 0 {
 				na := typecheck.NodAddr(typecheck.DotField(base.Pos, np, i))
-				call.Args.Append(na)
-				call.Args.Append(nh)
+				call := hashCall(f.Type, na, nh)
 				fn.Body.Append(ir.NewAssignStmt(base.Pos, nh, call))
 				i++
 				continue
@@ -252,29 +323,29 @@ func runtimeHashFor(name string, t *types.Type) *ir.Name {
 	return typecheck.LookupRuntime(name, t)
 }
 
-// hashfor returns the function to compute the hash of a value of type t.
-func hashfor(t *types.Type) *ir.Name {
-	switch types.AlgType(t) {
-	case types.AMEM:
-		base.Fatalf("hashfor with AMEM type")
-	case types.AINTER:
-		return runtimeHashFor("interhash", t)
-	case types.ANILINTER:
-		return runtimeHashFor("nilinterhash", t)
-	case types.ASTRING:
-		return runtimeHashFor("strhash", t)
-	case types.AFLOAT32:
-		return runtimeHashFor("f32hash", t)
-	case types.AFLOAT64:
-		return runtimeHashFor("f64hash", t)
-	case types.ACPLX64:
-		return runtimeHashFor("c64hash", t)
-	case types.ACPLX128:
-		return runtimeHashFor("c128hash", t)
+// hashCall returns a call that hashes the value of type t at address p,
+// starting from hash h. Types that need generated hash functions, as
+// reported by types.NeedsGeneratedHash, call theirs. Plain memory only
+// reaches hashCall under -d=algnomem, as a struct field or array element
+// hashed on its own, and uses the runtime hasher for its width, such as
+// memhash64, or memhash if there is none. Other types call their runtime
+// hasher.
+func hashCall(t *types.Type, p, h ir.Node) *ir.CallExpr {
+	switch {
+	case types.NeedsGeneratedHash(t):
+		return ir.NewCallExpr(base.Pos, ir.OCALL, hashFunc(t).Nname, []ir.Node{p, h})
+	case compare.IsRegularMemory(t):
+		if name := runtimeHashName(t); name != "" {
+			up := typecheck.ConvNop(p, types.Types[types.TUNSAFEPTR])
+			return ir.NewCallExpr(base.Pos, ir.OCALL, typecheck.LookupRuntime(name), []ir.Node{up, h})
+		}
+		return ir.NewCallExpr(base.Pos, ir.OCALL, hashmem(t), []ir.Node{p, h, ir.NewInt(base.Pos, t.Size())})
 	}
-
-	fn := hashFunc(t)
-	return fn.Nname
+	name := runtimeHashName(t)
+	if name == "" {
+		base.Fatalf("hashCall %v", t)
+	}
+	return ir.NewCallExpr(base.Pos, ir.OCALL, runtimeHashFor(name, t), []ir.Node{p, h})
 }
 
 // sysClosure returns a closure which will call the
@@ -289,41 +360,39 @@ func sysClosure(name string) *obj.LSym {
 	return s
 }
 
+// MaxUnrolledEqElems is the largest number of elements of an array for
+// which generated equality functions compare every element in straight
+// line code. Larger arrays are compared in loops, with a few elements per
+// iteration, so that code size does not grow with the array length. The
+// default, 1, loops whenever a pass over the elements would iterate more
+// than once.
+var MaxUnrolledEqElems int64 = 1
+
+// ShouldLoopCompare reports whether the generated equality function for
+// array type t compares its elements in a loop rather than fully
+// unrolled, in a pass that compares unroll elements per iteration. A
+// loop that would run only once is unrolled. It is false for arrays
+// that do not need a generated function.
+func ShouldLoopCompare(t *types.Type, unroll int64) bool {
+	if !t.IsArray() || !types.NeedsGeneratedEq(t) {
+		return false
+	}
+	nelem := t.NumElem()
+	return nelem > MaxUnrolledEqElems && nelem/unroll > 1
+}
+
 // geneq returns a symbol which is the closure used to compute
 // equality for two objects of type t.
 func geneq(t *types.Type) *obj.LSym {
-	switch AlgType(t) {
-	case types.ANOEQ, types.ANOALG:
-		// The runtime will panic if it tries to compare
-		// a type with a nil equality function.
-		return nil
-	case types.AMEM0:
-		return sysClosure("memequal0")
-	case types.AMEM8:
-		return sysClosure("memequal8")
-	case types.AMEM16:
-		return sysClosure("memequal16")
-	case types.AMEM32:
-		return sysClosure("memequal32")
-	case types.AMEM64:
-		return sysClosure("memequal64")
-	case types.AMEM128:
-		return sysClosure("memequal128")
-	case types.ASTRING:
-		return sysClosure("strequal")
-	case types.AINTER:
-		return sysClosure("interequal")
-	case types.ANILINTER:
-		return sysClosure("nilinterequal")
-	case types.AFLOAT32:
-		return sysClosure("f32equal")
-	case types.AFLOAT64:
-		return sysClosure("f64equal")
-	case types.ACPLX64:
-		return sysClosure("c64equal")
-	case types.ACPLX128:
-		return sysClosure("c128equal")
-	case types.AMEM:
+	if !types.NeedsGeneratedEq(t) {
+		if name := runtimeEqName(t); name != "" {
+			return sysClosure(name)
+		}
+		if AlgType(t) != types.AMEM {
+			// The runtime will panic if it tries to compare
+			// a type with a nil equality function.
+			return nil
+		}
 		// make equality closure. The size of the type
 		// is encoded in the closure.
 		closure := TypeLinksymLookup(fmt.Sprintf(".eqfunc%d", t.Size()))
@@ -338,8 +407,6 @@ func geneq(t *types.Type) *obj.LSym {
 		ot = objw.Uintptr(closure, ot, uint64(t.Size()))
 		objw.Global(closure, int32(ot), obj.DUPOK|obj.RODATA)
 		return closure
-	case types.ASPECIAL:
-		break
 	}
 
 	closure := TypeLinksymPrefix(".eqfunc", t)
@@ -438,11 +505,9 @@ func eqFunc(t *types.Type) *ir.Func {
 				return eq(pi, qi)
 			}
 
-			iterations := nelem / unroll
-			iterateTo := iterations * unroll
-			// If a loop is iterated only once, there shouldn't be any loop at all.
-			if iterations == 1 {
-				iterateTo = 0
+			iterateTo := int64(0)
+			if ShouldLoopCompare(t, unroll) {
+				iterateTo = nelem / unroll * unroll
 			}
 
 			if iterateTo > 0 {
@@ -640,15 +705,58 @@ func eqFunc(t *types.Type) *ir.Func {
 	return fn
 }
 
+// runtimeEqName returns the name of the runtime function that compares
+// values of type t, or "" if there is none: t is plain memory of a size
+// without its own function, needs a generated equality function, or is
+// not comparable.
+func runtimeEqName(t *types.Type) string {
+	switch AlgType(t) {
+	case types.AMEM0:
+		return "memequal0"
+	case types.AMEM8:
+		return "memequal8"
+	case types.AMEM16:
+		return "memequal16"
+	case types.AMEM32:
+		return "memequal32"
+	case types.AMEM64:
+		return "memequal64"
+	case types.AMEM128:
+		return "memequal128"
+	case types.AMEM256:
+		return "memequal256"
+	case types.ASTRING:
+		return "strequal"
+	case types.AINTER:
+		return "interequal"
+	case types.ANILINTER:
+		return "nilinterequal"
+	case types.AFLOAT32:
+		return "f32equal"
+	case types.AFLOAT64:
+		return "f64equal"
+	case types.ACPLX64:
+		return "c64equal"
+	case types.ACPLX128:
+		return "c128equal"
+	case types.AFLOAT32x4:
+		return "f32x4equal"
+	case types.AFLOAT64x2:
+		return "f64x2equal"
+	case types.AFLOAT64x4:
+		return "f64x4equal"
+	}
+	return ""
+}
+
 // EqFor returns ONAME node represents type t's equal function, and a boolean
 // to indicates whether a length needs to be passed when calling the function.
 func EqFor(t *types.Type) (ir.Node, bool) {
-	switch types.AlgType(t) {
-	case types.AMEM:
+	switch {
+	case types.NeedsGeneratedEq(t):
+		return eqFunc(t).Nname, false
+	case compare.IsRegularMemory(t):
 		return typecheck.LookupRuntime("memequal", t, t), true
-	case types.ASPECIAL:
-		fn := eqFunc(t)
-		return fn.Nname, false
 	}
 	base.Fatalf("EqFor %v", t)
 	return nil, false
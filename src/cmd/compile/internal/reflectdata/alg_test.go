@@ -5,9 +5,204 @@
 package reflectdata_test
 
 import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"internal/abi"
+	"internal/testenv"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"unsafe"
+
+	"cmd/compile/internal/base"
+	"cmd/compile/internal/reflectdata"
+	"cmd/compile/internal/typecheck"
+	"cmd/compile/internal/types"
+	"cmd/internal/obj"
+	"cmd/internal/src"
+	"cmd/internal/sys"
 )
 
+func init() {
+	// These are the few constants that need to be initialized in order to use
+	// the types package without using the typecheck package by calling
+	// typecheck.InitUniverse() (the normal way to initialize the types package).
+	types.PtrSize = 8
+	types.RegSize = 8
+	types.MaxWidth = 1 << 50
+	base.Ctxt = &obj.Link{Arch: &obj.LinkArch{Arch: &sys.Arch{Alignment: 1, CanMergeLoads: true}}}
+	typecheck.InitUniverse()
+}
+
+func mkstruct(fieldTypes ...*types.Type) *types.Type {
+	fields := make([]*types.Field, len(fieldTypes))
+	for i, ftyp := range fieldTypes {
+		fields[i] = types.NewField(src.NoXPos, typecheck.LookupNum("f", i), ftyp)
+	}
+	typ := types.NewStruct(fields)
+	types.CalcSize(typ)
+	return typ
+}
+
+func TestAlgTypeMem256(t *testing.T) {
+	u64 := types.Types[types.TUINT64]
+	tests := []struct {
+		name string
+		typ  *types.Type
+		want types.AlgKind
+	}{
+		{"[32]byte", types.NewArray(types.ByteType, 32), types.AMEM256},
+		{"struct of four uint64", mkstruct(u64, u64, u64, u64), types.AMEM256},
+		{"[16]byte", types.NewArray(types.ByteType, 16), types.AMEM128},
+		{"257-byte struct", mkstruct(types.NewArray(types.ByteType, 257)), types.AMEM},
+	}
+	for _, tc := range tests {
+		if got := reflectdata.AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestWidestMemAlg checks that AlgType caps the fixed-width AMEM
+// variants at WidestMemAlg, for a 64-bit and a 32-bit target.
+func TestWidestMemAlg(t *testing.T) {
+	u32 := types.Types[types.TUINT32]
+	typs := []*types.Type{
+		types.NewArray(types.ByteType, 4),
+		types.NewArray(types.ByteType, 8),
+		mkstruct(u32, u32, u32, u32),
+		types.NewArray(types.ByteType, 32),
+	}
+	tests := []struct {
+		regSize int
+		widest  types.AlgKind
+		want    []types.AlgKind
+	}{
+		{8, types.AMEM256, []types.AlgKind{types.AMEM32, types.AMEM64, types.AMEM128, types.AMEM256}},
+		{4, types.AMEM128, []types.AlgKind{types.AMEM32, types.AMEM64, types.AMEM128, types.AMEM}},
+	}
+	defer func(r int) { types.RegSize = r }(types.RegSize)
+	for _, tc := range tests {
+		types.RegSize = tc.regSize
+		if got := reflectdata.WidestMemAlg(); got != tc.widest {
+			t.Errorf("RegSize %d: WidestMemAlg() = %v, want %v", tc.regSize, got, tc.widest)
+		}
+		for i, typ := range typs {
+			if got := reflectdata.AlgType(typ); got != tc.want[i] {
+				t.Errorf("RegSize %d: AlgType(%v) = %v, want %v", tc.regSize, typ, got, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestWidestMemAlgEmitted(t *testing.T) {
+	// The equality and hash functions in type descriptors must follow
+	// WidestMemAlg on 64-bit and 32-bit targets alike.
+	testenv.MustHaveGoBuild(t)
+	t.Parallel()
+
+	const src = `
+package p
+
+type K16 [16]byte
+type K32 [32]byte
+
+var Sink = []any{K16{}, K32{}}
+var M1 map[K16]int
+var M2 map[K32]int
+`
+	dir := t.TempDir()
+	file := filepath.Join(dir, "p.go")
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		goarch string
+		sym    string
+		want   string
+	}{
+		{"amd64", "type:p.K16", "runtime.memequal128·f"},
+		{"amd64", "type:map[p.K16]int", "runtime.memhash128·f"},
+		{"amd64", "type:p.K32", "runtime.memequal256·f"},
+		{"amd64", "type:map[p.K32]int", "runtime.memhash256·f"},
+		{"386", "type:p.K16", "runtime.memequal128·f"},
+		{"386", "type:map[p.K16]int", "runtime.memhash128·f"},
+		{"386", "type:p.K32", "type:.eqfunc32"},
+		{"386", "type:map[p.K32]int", "type:.hashfunc32"},
+	}
+	relocs := map[string]map[string]string{}
+	for _, tc := range tests {
+		if relocs[tc.goarch] != nil {
+			continue
+		}
+		cmd := testenv.Command(t, testenv.GoToolPath(t), "tool", "compile", "-p=p", "-S", "-o", filepath.Join(dir, "p.o"), file)
+		cmd.Env = append(os.Environ(), "GOARCH="+tc.goarch, "GOOS=linux")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("GOARCH=%s: compile failed: %v\n%s", tc.goarch, err, out)
+		}
+		// Collect the relocations of each symbol in the listing.
+		m := map[string]string{}
+		var sym string
+		for _, line := range strings.Split(string(out), "\n") {
+			if f := strings.Fields(line); len(f) > 0 {
+				if !strings.HasPrefix(line, "\t") {
+					sym = f[0]
+				} else if f[0] == "rel" {
+					m[sym] += f[len(f)-1] + "\n"
+				}
+			}
+		}
+		relocs[tc.goarch] = m
+	}
+	for _, tc := range tests {
+		if got := relocs[tc.goarch][tc.sym]; !strings.Contains(got, tc.want+"+0\n") {
+			t.Errorf("GOARCH=%s: %s does not refer to %s; relocations:\n%s", tc.goarch, tc.sym, tc.want, got)
+		}
+	}
+}
+
+func TestEqMemWidths(t *testing.T) {
+	// Values of every fixed memory width must compare and hash equal
+	// exactly when all their bytes are, whichever runtime function
+	// WidestMemAlg selects for them on this target.
+	check := func(name string, n int, eq func(i int) (same, diff, lookup bool)) {
+		for i := 0; i < n; i++ {
+			same, diff, lookup := eq(i)
+			if !same || diff || !lookup {
+				t.Errorf("%s: byte %d: equal = %v, unequal compared equal = %v, map lookup = %v", name, i, same, diff, lookup)
+			}
+		}
+	}
+	type pair struct{ a, b uint64 }
+	check("[16]byte", 16, func(i int) (bool, bool, bool) {
+		var a [16]byte
+		b := a
+		b[i] = 1
+		m := map[[16]byte]int{a: 1, b: 2}
+		return any(a) == any([16]byte{}), any(a) == any(b), m[a] == 1 && m[b] == 2
+	})
+	check("struct{a, b uint64}", 16, func(i int) (bool, bool, bool) {
+		var a pair
+		b := a
+		(*[16]byte)(unsafe.Pointer(&b))[i] = 1
+		m := map[pair]int{a: 1, b: 2}
+		return any(a) == any(pair{}), any(a) == any(b), m[a] == 1 && m[b] == 2
+	})
+	check("[32]byte", 32, func(i int) (bool, bool, bool) {
+		var a [32]byte
+		b := a
+		b[i] = 1
+		m := map[[32]byte]int{a: 1, b: 2}
+		return any(a) == any([32]byte{}), any(a) == any(b), m[a] == 1 && m[b] == 2
+	})
+}
+
 func BenchmarkEqArrayOfStrings5(b *testing.B) {
 	var a [5]string
 	var c [5]string
@@ -50,6 +245,23 @@ func BenchmarkEqArrayOfStrings1024(b *testing.B) {
 	}
 }
 
+func BenchmarkEqArrayOfStringStructs64(b *testing.B) {
+	// Elements wrapping a single string should compare as fast as
+	// plain strings.
+	type S struct{ s string }
+	var a [64]S
+	var c [64]S
+
+	for i := 0; i < 64; i++ {
+		a[i].s = "aaaa"
+		c[i].s = "cccc"
+	}
+
+	for j := 0; j < b.N; j++ {
+		_ = a == c
+	}
+}
+
 func BenchmarkEqArrayOfFloats5(b *testing.B) {
 	var a [5]float32
 	var c [5]float32
@@ -145,3 +357,574 @@ func BenchmarkEqStruct(b *testing.B) {
 		}
 	}
 }
+
+// TestAlgTypeMemRuntime checks that each fixed-width AMEM variant is
+// chosen for exactly the size handled by its runtime helpers, and that
+// the runtime defines those helpers.
+func TestAlgTypeMemRuntime(t *testing.T) {
+	sizes := map[types.AlgKind]int64{
+		types.AMEM0:   0,
+		types.AMEM8:   1,
+		types.AMEM16:  2,
+		types.AMEM32:  4,
+		types.AMEM64:  8,
+		types.AMEM128: 16,
+		types.AMEM256: 32,
+	}
+
+	runtimeFuncs := make(map[string]bool)
+	dir := filepath.Join(testenv.GOROOT(t), "src", "runtime")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range f.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv == nil {
+				runtimeFuncs[fd.Name.Name] = true
+			}
+		}
+	}
+
+	for a := types.AMEM0; a <= types.AMEM256; a++ {
+		size, ok := sizes[a]
+		if !ok {
+			t.Errorf("no size known for %v", a)
+			continue
+		}
+		typ := types.NewArray(types.ByteType, size)
+		if got := reflectdata.AlgType(typ); got != a {
+			t.Errorf("AlgType([%d]byte) = %v, want %v", size, got, a)
+		}
+		for _, prefix := range []string{"memequal", "memhash"} {
+			if name := fmt.Sprintf("%s%d", prefix, size*8); !runtimeFuncs[name] {
+				t.Errorf("runtime does not define %s for %v", name, a)
+			}
+		}
+	}
+}
+
+func TestAlgTypeMultiFieldStruct(t *testing.T) {
+	u8 := types.Types[types.TUINT8]
+	u32 := types.Types[types.TUINT32]
+	u64 := types.Types[types.TUINT64]
+
+	// struct{a uint8; b uint8; _ [2]byte; c uint32}
+	blank := types.NewStruct([]*types.Field{
+		types.NewField(src.NoXPos, typecheck.Lookup("a"), u8),
+		types.NewField(src.NoXPos, typecheck.Lookup("b"), u8),
+		types.NewField(src.NoXPos, types.BlankSym, types.NewArray(types.ByteType, 2)),
+		types.NewField(src.NoXPos, typecheck.Lookup("c"), u32),
+	})
+	types.CalcSize(blank)
+
+	tests := []struct {
+		name string
+		typ  *types.Type
+		want types.AlgKind
+	}{
+		{"struct{a, b uint32}", mkstruct(u32, u32), types.AMEM64},
+		{"struct{a, b uint64}", mkstruct(u64, u64), types.AMEM128},
+		{"struct{a, b uint32; c uint64}", mkstruct(u32, u32, u64), types.AMEM128},
+		{"struct{a uint8; b uint8; _ [2]byte; c uint32}", blank, types.ASPECIAL},
+		{"struct{a uint8; b uint32}", mkstruct(u8, u32), types.ASPECIAL},
+	}
+	for _, tc := range tests {
+		if got := reflectdata.AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAlgTypeBoolStruct(t *testing.T) {
+	b := types.Types[types.TBOOL]
+	tests := []struct {
+		name string
+		typ  *types.Type
+		want types.AlgKind
+	}{
+		{"struct{a bool}", mkstruct(b), types.AMEM8},
+		{"struct{a, b bool}", mkstruct(b, b), types.AMEM16},
+		{"struct{a, b, c bool}", mkstruct(b, b, b), types.AMEM},
+		{"struct{a, b, c, d bool}", mkstruct(b, b, b, b), types.AMEM32},
+		{"struct{a bool; b int16}", mkstruct(b, types.Types[types.TINT16]), types.ASPECIAL},
+		{"struct{a bool; b int8; c int16}", mkstruct(b, types.Types[types.TINT8], types.Types[types.TINT16]), types.AMEM32},
+	}
+	for _, tc := range tests {
+		if got := reflectdata.AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// Pointers are compared by address, like integers, so structs of
+// pointers collapse to a single memory comparison too. Comparing them
+// only reads the pointer words and never writes them, so it needs no
+// write barriers.
+func TestAlgTypePointerStruct(t *testing.T) {
+	p := types.NewPtr(types.Types[types.TINT])
+	up := types.Types[types.TUNSAFEPTR]
+	uptr := types.Types[types.TUINTPTR]
+	tests := []struct {
+		name string
+		typ  *types.Type
+		want types.AlgKind
+	}{
+		{"struct{a *int}", mkstruct(p), types.AMEM64},
+		{"struct{a unsafe.Pointer}", mkstruct(up), types.AMEM64},
+		{"struct{a, b *int}", mkstruct(p, p), types.AMEM128},
+		{"struct{a *int; b uintptr}", mkstruct(p, uptr), types.AMEM128},
+		{"struct{a uintptr; b unsafe.Pointer}", mkstruct(uptr, up), types.AMEM128},
+		{"struct{a, b, c *int}", mkstruct(p, p, p), types.AMEM},
+		{"[2]*int", types.NewArray(p, 2), types.AMEM128},
+		{"struct{a *int; b byte}", mkstruct(p, types.ByteType), types.ASPECIAL},
+	}
+	for _, tc := range tests {
+		if got := reflectdata.AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestShouldLoopCompare(t *testing.T) {
+	str := types.Types[types.TSTRING]
+	pair := mkstruct(types.Types[types.TUINT64], str)
+	tests := []struct {
+		name   string
+		typ    *types.Type
+		unroll int64
+		want   bool
+	}{
+		{"[1]string", types.NewArray(str, 1), 1, false},
+		{"[2]string", types.NewArray(str, 2), 1, true},
+		{"[5]string", types.NewArray(str, 5), 3, false},
+		{"[6]string", types.NewArray(str, 6), 3, true},
+		{"[2]struct{x uint64; y string}", types.NewArray(pair, 2), 4, false},
+		{"[1024]struct{x uint64; y string}", types.NewArray(pair, 1024), 4, true},
+		{"[1024]byte", types.NewArray(types.ByteType, 1024), 1, false},
+		{"[4]float32", types.NewArray(types.Types[types.TFLOAT32], 4), 2, false},
+		{"[8]float32", types.NewArray(types.Types[types.TFLOAT32], 8), 2, true},
+	}
+	for _, tc := range tests {
+		if got := reflectdata.ShouldLoopCompare(tc.typ, tc.unroll); got != tc.want {
+			t.Errorf("ShouldLoopCompare(%s, %d) = %v, want %v", tc.name, tc.unroll, got, tc.want)
+		}
+	}
+
+	defer func(n int64) { reflectdata.MaxUnrolledEqElems = n }(reflectdata.MaxUnrolledEqElems)
+	reflectdata.MaxUnrolledEqElems = 8
+	if reflectdata.ShouldLoopCompare(types.NewArray(str, 5), 1) {
+		t.Errorf("ShouldLoopCompare([5]string, 1) = true with MaxUnrolledEqElems = 8, want false")
+	}
+	if !reflectdata.ShouldLoopCompare(types.NewArray(str, 9), 1) {
+		t.Errorf("ShouldLoopCompare([9]string, 1) = false with MaxUnrolledEqElems = 8, want true")
+	}
+}
+
+func TestEqArrayUnrollBoundary(t *testing.T) {
+	// Arrays whose generated equality functions are unrolled and ones
+	// whose functions loop must compare equal exactly when all elements
+	// are.
+	check := func(name string, n int, eq func(i int) (bool, bool)) {
+		for i := 0; i < n; i++ {
+			same, diff := eq(i)
+			if !same || diff {
+				t.Errorf("%s: differing at %d: equal = %v, unequal compared equal = %v", name, i, same, diff)
+			}
+		}
+	}
+	check("[4]string", 4, func(i int) (bool, bool) {
+		a := [4]string{"a", "b", "c", "d"}
+		b := a
+		same := a == b
+		b[i] = "x"
+		return same, a == b
+	})
+	check("[5]string", 5, func(i int) (bool, bool) {
+		a := [5]string{"a", "b", "c", "d", "e"}
+		b := a
+		same := a == b
+		b[i] = "x"
+		return same, a == b
+	})
+	type pair struct {
+		x uint64
+		y string
+	}
+	check("[9]pair", 9, func(i int) (bool, bool) {
+		var a [9]pair
+		for j := range a {
+			a[j] = pair{uint64(j), fmt.Sprint(j)}
+		}
+		b := a
+		same := a == b
+		b[i].y = "x"
+		return same, a == b
+	})
+}
+
+func TestNeedsGeneratedEq(t *testing.T) {
+	// NeedsGeneratedEq must agree with whether the compiler emits a
+	// generated equality function for the type's descriptor.
+	testenv.MustHaveGoBuild(t)
+	t.Parallel()
+
+	i8, i64 := types.Types[types.TINT8], types.Types[types.TINT64]
+	f32, str := types.Types[types.TFLOAT32], types.Types[types.TSTRING]
+	tests := []struct {
+		decl string
+		typ  *types.Type
+	}{
+		{"int64", i64},
+		{"string", str},
+		{"any", types.Types[types.TINTER]},
+		{"[24]byte", types.NewArray(types.ByteType, 24)},
+		{"struct{ a, b int64 }", mkstruct(i64, i64)},
+		{"[4]float32", types.NewArray(f32, 4)},
+		{"[3]float32", types.NewArray(f32, 3)},
+		{"struct{ a int8; b int64 }", mkstruct(i8, i64)},
+		{"struct{ a int64; b string }", mkstruct(i64, str)},
+		{"[5]string", types.NewArray(str, 5)},
+	}
+
+	var src strings.Builder
+	src.WriteString("package p\n\nvar Sink []any\n")
+	for i, tc := range tests {
+		fmt.Fprintf(&src, "\ntype T%d %s\n\nfunc init() { Sink = append(Sink, *new(T%[1]d)) }\n", i, tc.decl)
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "p.go")
+	if err := os.WriteFile(file, []byte(src.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := testenv.Command(t, testenv.GoToolPath(t), "tool", "compile", "-p=p", "-S", "-o", filepath.Join(dir, "p.o"), file)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("compile failed: %v\n%s", err, out)
+	}
+
+	for i, tc := range tests {
+		emitted := strings.Contains(string(out), fmt.Sprintf("type:.eq.p.T%d STEXT", i))
+		if got := types.NeedsGeneratedEq(tc.typ); got != emitted {
+			t.Errorf("NeedsGeneratedEq(%s) = %v, but generated equality function emitted = %v", tc.decl, got, emitted)
+		}
+	}
+}
+
+func TestTypeAlgFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  *types.Type
+		want abi.TFlag
+	}{
+		{"int", types.Types[types.TINT], abi.TFlagRegularMemory},
+		{"*int", types.NewPtr(types.Types[types.TINT]), abi.TFlagRegularMemory},
+		{"struct{a, b int32}", mkstruct(types.Types[types.TINT32], types.Types[types.TINT32]), abi.TFlagRegularMemory},
+		{"[4]uint8", types.NewArray(types.ByteType, 4), abi.TFlagRegularMemory},
+		{"string", types.Types[types.TSTRING], 0},
+		{"float64", types.Types[types.TFLOAT64], 0},
+		{"struct{a int8; b int64}", mkstruct(types.Types[types.TINT8], types.Types[types.TINT64]), 0},
+		{"[]int", types.NewSlice(types.Types[types.TINT]), 0},
+		{"map[int]int", types.NewMap(types.Types[types.TINT], types.Types[types.TINT]), 0},
+	}
+	for _, tc := range tests {
+		if got := reflectdata.TypeAlgFlags(tc.typ); got != tc.want {
+			t.Errorf("TypeAlgFlags(%s) = %#x, want %#x", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestConstStringCompareKind(t *testing.T) {
+	tests := []struct {
+		s    string
+		want types.AlgKind
+	}{
+		{"", types.AMEM0},
+		{"a", types.AMEM8},
+		{"ab", types.AMEM16},
+		{"abc", types.ASTRING},
+		{"abcd", types.AMEM32},
+		{"abcdefgh", types.AMEM64},
+		{"abcdefghijklmnop", types.ASTRING},
+	}
+	for _, tc := range tests {
+		if got := reflectdata.ConstStringCompareKind(int64(len(tc.s))); got != tc.want {
+			t.Errorf("ConstStringCompareKind(len(%q)) = %v, want %v", tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestKeyHashKind(t *testing.T) {
+	keys := []struct {
+		name string
+		typ  *types.Type
+	}{
+		{"uintptr", types.Types[types.TUINTPTR]},
+		{"unsafe.Pointer", types.Types[types.TUNSAFEPTR]},
+		{"*int", types.NewPtr(types.Types[types.TINT])},
+		{"struct{p *int}", mkstruct(types.NewPtr(types.Types[types.TINT]))},
+		{"uint64", types.Types[types.TUINT64]},
+	}
+	for _, k := range keys {
+		if got := reflectdata.KeyHashKind(k.typ); got != types.AMEM64 {
+			t.Errorf("KeyHashKind(%s) = %v, want %v", k.name, got, types.AMEM64)
+		}
+	}
+}
+
+func TestAlgTypePointerShapedStruct(t *testing.T) {
+	for _, ptr := range []*types.Type{types.NewPtr(types.Types[types.TINT]), types.Types[types.TUNSAFEPTR]} {
+		s := mkstruct(ptr)
+		if s.Size() != int64(types.PtrSize) {
+			t.Errorf("Size(%v) = %d, want %d", s, s.Size(), types.PtrSize)
+		}
+		if got, want := reflectdata.AlgType(s), reflectdata.AlgType(ptr); got != want {
+			t.Errorf("AlgType(%v) = %v, want %v, as for %v", s, got, want, ptr)
+		}
+		if !types.EqualAlg(s, ptr) {
+			t.Errorf("EqualAlg(%v, %v) = false, want true", s, ptr)
+		}
+	}
+}
+
+func TestEqPointerStruct(t *testing.T) {
+	type pair struct{ a, b *int }
+	type mixed struct {
+		p *int
+		u uintptr
+	}
+	x, y := new(int), new(int)
+	m := map[pair]int{}
+	for i := 0; i < 100; i++ {
+		m[pair{x, y}]++
+		// Hashing and comparing the keys must give the same
+		// results across collections.
+		runtime.GC()
+	}
+	if got := m[pair{x, y}]; got != 100 || len(m) != 1 {
+		t.Errorf("m[pair{x, y}] = %d, len(m) = %d, want 100, 1", got, len(m))
+	}
+	if (pair{x, y}) == (pair{y, x}) {
+		t.Errorf("pair{x, y} == pair{y, x}, want false")
+	}
+	type wrapped struct{ p *int }
+	if any(wrapped{x}) != any(wrapped{x}) || any(wrapped{x}) == any(wrapped{y}) {
+		t.Errorf("wrapped pointer comparisons gave wrong results")
+	}
+	if (mixed{x, 1}) != (mixed{x, 1}) || (mixed{x, 1}) == (mixed{x, 2}) {
+		t.Errorf("mixed comparisons gave wrong results")
+	}
+}
+
+//go:noinline
+func eqPtrStruct[T comparable](a, b T) bool {
+	return a == b
+}
+
+func TestEqNilPointerStruct(t *testing.T) {
+	type ptr struct{ p *int }
+	type ptrPair struct{ p, q *int }
+	x, y := new(int), new(int)
+	typ := mkstruct(types.NewPtr(types.Types[types.TINT]))
+	if got := reflectdata.AlgType(typ); got != types.AMEM64 {
+		t.Errorf("AlgType(struct{p *int}) = %v, want %v", got, types.AMEM64)
+	}
+	tests := []struct {
+		name string
+		a, b ptr
+		want bool
+	}{
+		{"nil, nil", ptr{}, ptr{nil}, true},
+		{"nil, non-nil", ptr{}, ptr{x}, false},
+		{"non-nil, nil", ptr{x}, ptr{}, false},
+		{"same pointer", ptr{x}, ptr{x}, true},
+		{"different pointers", ptr{x}, ptr{y}, false},
+	}
+	for _, tc := range tests {
+		if got := tc.a == tc.b; got != tc.want {
+			t.Errorf("%s: == gave %v, want %v", tc.name, got, tc.want)
+		}
+		if got := eqPtrStruct(tc.a, tc.b); got != tc.want {
+			t.Errorf("%s: generic == gave %v, want %v", tc.name, got, tc.want)
+		}
+		if got := any(tc.a) == any(tc.b); got != tc.want {
+			t.Errorf("%s: interface == gave %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if !eqPtrStruct(ptrPair{x, nil}, ptrPair{x, nil}) || eqPtrStruct(ptrPair{x, nil}, ptrPair{nil, x}) {
+		t.Errorf("ptrPair comparisons with nil fields gave wrong results")
+	}
+
+	m := map[ptr]int{{}: 1, {x}: 2}
+	if m[ptr{}] != 1 || m[ptr{x}] != 2 || len(m) != 2 {
+		t.Errorf("map[ptr]int lookups gave %d, %d, len %d, want 1, 2, len 2", m[ptr{}], m[ptr{x}], len(m))
+	}
+	if _, ok := m[ptr{y}]; ok {
+		t.Errorf("lookup of unset pointer key succeeded")
+	}
+}
+
+// allBlank and mixedBlank are used through unsafe to give their blank
+// fields different contents.
+type allBlank struct {
+	_ int
+	_ string
+}
+
+type mixedBlank struct {
+	_ int
+	x int
+}
+
+func TestEqAllBlank(t *testing.T) {
+	var a, b allBlank
+	*(*int)(unsafe.Pointer(&a)) = 1
+	*(*int)(unsafe.Pointer(&b)) = 2
+	if a != b {
+		t.Errorf("all-blank structs with different blank contents compare unequal")
+	}
+	if x, y := any(a), any(b); x != y {
+		t.Errorf("all-blank structs in interfaces compare unequal")
+	}
+	m := map[allBlank]int{a: 1, b: 2}
+	if len(m) != 1 || m[a] != 2 {
+		t.Errorf("map with all-blank keys = %v, want one entry", m)
+	}
+
+	var c, d mixedBlank
+	*(*int)(unsafe.Pointer(&c)) = 1
+	*(*int)(unsafe.Pointer(&d)) = 2
+	if c != d {
+		t.Errorf("mixed structs with different blank contents compare unequal")
+	}
+	d.x = 1
+	if c == d {
+		t.Errorf("mixed structs with different non-blank fields compare equal")
+	}
+}
+
+func TestAlgTypeFloatVector(t *testing.T) {
+	f32 := types.Types[types.TFLOAT32]
+	f64 := types.Types[types.TFLOAT64]
+	c64 := types.Types[types.TCOMPLEX64]
+	c128 := types.Types[types.TCOMPLEX128]
+	tests := []struct {
+		name string
+		typ  *types.Type
+		want types.AlgKind
+	}{
+		{"[4]float32", types.NewArray(f32, 4), types.AFLOAT32x4},
+		{"[2]float64", types.NewArray(f64, 2), types.AFLOAT64x2},
+		{"[4]float64", types.NewArray(f64, 4), types.AFLOAT64x4},
+		{"[2]complex64", types.NewArray(c64, 2), types.AFLOAT32x4},
+		{"[2]complex128", types.NewArray(c128, 2), types.AFLOAT64x4},
+		{"[3]float32", types.NewArray(f32, 3), types.ASPECIAL},
+		{"[8]float64", types.NewArray(f64, 8), types.ASPECIAL},
+		{"[3]complex128", types.NewArray(c128, 3), types.ASPECIAL},
+		{"[1]float64", types.NewArray(f64, 1), types.AFLOAT64},
+		{"[1]complex128", types.NewArray(c128, 1), types.ACPLX128},
+		{"struct{a, b float64}", mkstruct(f64, f64), types.ASPECIAL},
+	}
+	for _, tc := range tests {
+		if got := reflectdata.AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEqFloatVector(t *testing.T) {
+	// Comparing through interfaces and using map keys goes through the
+	// runtime helpers selected for these types.
+	nan := math.NaN()
+	negZero := math.Copysign(0, -1)
+
+	a := [2]float64{nan, 1}
+	if any(a) == any(a) {
+		t.Errorf("%v == %v, want false", a, a)
+	}
+	if b := [2]float64{negZero, 1}; any(b) != any([2]float64{0, 1}) {
+		t.Errorf("%v != %v, want true", b, [2]float64{0, 1})
+	}
+	c := [4]float32{1, 2, float32(nan), 4}
+	if any(c) == any(c) {
+		t.Errorf("%v == %v, want false", c, c)
+	}
+	if d := [4]float32{1, 2, 3, 4}; any(d) != any([4]float32{1, 2, 3, 4}) {
+		t.Errorf("%v != %v, want true", d, d)
+	}
+
+	m64 := map[[2]float64]int{{0, 1}: 1}
+	if m64[[2]float64{negZero, 1}] != 1 {
+		t.Errorf("lookup of [2]float64{-0, 1} did not find key [2]float64{0, 1}")
+	}
+	m64[a] = 2
+	m64[a] = 3
+	if len(m64) != 3 {
+		t.Errorf("map with NaN-containing keys has %d entries, want 3", len(m64))
+	}
+	m32 := map[[4]float32]int{{0, 1, 2, 3}: 1}
+	if m32[[4]float32{float32(negZero), 1, 2, 3}] != 1 {
+		t.Errorf("lookup of [4]float32{-0, 1, 2, 3} did not find key [4]float32{0, 1, 2, 3}")
+	}
+
+	// Complex arrays compare and hash component-wise, so a signed zero
+	// in either the real or the imaginary part doesn't matter.
+	e := [2]complex128{complex(0, 0), complex(1, 0)}
+	for _, f := range [][2]complex128{
+		{complex(negZero, 0), complex(1, 0)},
+		{complex(0, negZero), complex(1, negZero)},
+		{complex(negZero, negZero), complex(1, 0)},
+	} {
+		if any(f) != any(e) {
+			t.Errorf("%v != %v, want true", f, e)
+		}
+	}
+	if g := [2]complex128{complex(nan, 0), 1}; any(g) == any(g) {
+		t.Errorf("%v == %v, want false", g, g)
+	}
+	m128 := map[[2]complex128]int{e: 1}
+	if m128[[2]complex128{complex(negZero, negZero), complex(1, negZero)}] != 1 {
+		t.Errorf("lookup of [2]complex128 with signed zeros did not find key %v", e)
+	}
+	m64c := map[[2]complex64]int{{0, 1}: 1}
+	if m64c[[2]complex64{complex(float32(negZero), 0), 1}] != 1 {
+		t.Errorf("lookup of [2]complex64 with signed zeros did not find key [2]complex64{0, 1}")
+	}
+}
+
+type sliceError []int
+
+func (sliceError) Error() string { return "sliceError" }
+
+func TestEqError(t *testing.T) {
+	var e1, e2 error = os.ErrNotExist, os.ErrNotExist
+	if e1 != e2 {
+		t.Errorf("%v != %v, want true", e1, e2)
+	}
+	if e1 == os.ErrExist {
+		t.Errorf("%v == %v, want false", e1, os.ErrExist)
+	}
+
+	// Errors holding different dynamic types compare unequal without
+	// calling an equality function, but errors holding the same
+	// incomparable dynamic type panic.
+	var s1, s2 error = sliceError{1}, sliceError{1}
+	if e1 == s1 {
+		t.Errorf("%v == %v, want false", e1, s1)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("comparing errors holding incomparable values did not panic")
+		}
+	}()
+	_ = s1 == s2
+}
@@ -658,6 +658,18 @@ var (
 	memequalvarlen *obj.LSym
 )
 
+// TypeAlgFlags returns the bits of the tflag field of t's runtime type
+// descriptor that are derived from t's AlgKind: TFlagRegularMemory for
+// types that can be compared and hashed as plain memory. Comparability
+// itself is not a flag; the runtime tests whether the descriptor's equal
+// function is nil.
+func TypeAlgFlags(t *types.Type) abi.TFlag {
+	if compare.IsRegularMemory(t) {
+		return abi.TFlagRegularMemory
+	}
+	return 0
+}
+
 // dcommontype dumps the contents of a reflect.rtype (runtime._type) to c.
 func dcommontype(c rttype.Cursor, t *types.Type) {
 	types.CalcSize(t)
@@ -702,9 +714,7 @@ func dcommontype(c rttype.Cursor, t *types.Type) {
 	if t.Sym() != nil && t.Sym().Name != "" {
 		tflag |= abi.TFlagNamed
 	}
-	if compare.IsRegularMemory(t) {
-		tflag |= abi.TFlagRegularMemory
-	}
+	tflag |= TypeAlgFlags(t)
 
 	exported := false
 	p := t.NameString()
@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package test
+
+import (
+	"encoding/json"
+	"internal/testenv"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const algJSONSrc = `
+package p
+
+type Mem struct{ a, b int64 }
+
+type Padded struct {
+	a int8
+	b int64
+}
+
+var M1 map[Mem]int
+var M2 map[Padded]int
+`
+
+func TestAlgJSON(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	t.Parallel()
+
+	tmpdir := t.TempDir()
+	src := filepath.Join(tmpdir, "p.go")
+	if err := os.WriteFile(src, []byte(algJSONSrc), 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	out := filepath.Join(tmpdir, "alg.json")
+	cmd := testenv.Command(t, testenv.GoToolPath(t), "tool", "compile", "-p=p", "-o", filepath.Join(tmpdir, "p.o"), "-d=algjson="+out, src)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("compile failed: %v\n%s", err, output)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []struct {
+		Name    string `json:"name"`
+		Kind    string `json:"kind"`
+		Size    int64  `json:"size"`
+		Padding int64  `json:"padding"`
+		Reason  string `json:"special-reason"`
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("parsing %s: %v\n%s", out, err, data)
+	}
+
+	found := 0
+	for _, r := range records {
+		switch r.Name {
+		case "p.Mem":
+			found++
+			if r.Kind != "MEM" || r.Size != 16 || r.Padding != 0 || r.Reason != "" {
+				t.Errorf("got record %+v for p.Mem, want kind MEM, size 16, and no padding", r)
+			}
+		case "p.Padded":
+			found++
+			if r.Kind != "SPECIAL" || r.Size != 16 || r.Padding != 7 || r.Reason != "padding after field a" {
+				t.Errorf("got record %+v for p.Padded, want kind SPECIAL, size 16, and 7 bytes of padding after field a", r)
+			}
+		}
+	}
+	if found != 2 {
+		t.Errorf("found %d of the records for p.Mem and p.Padded in\n%s", found, data)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package test
+
+import (
+	"internal/testenv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const algNoMemSrc = `
+package p
+
+type S5 struct{ a, b, c, d, e int64 }
+
+type A9 [9]int32
+
+var Sink = []any{S5{}, A9{}}
+var M1 map[S5]int
+var M2 map[A9]int
+`
+
+// TestAlgNoMem checks that under -d=algnomem the generated equality and
+// hash functions of memory-comparable types work component by component,
+// without memequal or memhash calls covering several fields or elements.
+func TestAlgNoMem(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	t.Parallel()
+
+	tmpdir := t.TempDir()
+	src := filepath.Join(tmpdir, "p.go")
+	if err := os.WriteFile(src, []byte(algNoMemSrc), 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+	cmd := testenv.Command(t, testenv.GoToolPath(t), "tool", "compile", "-p=p", "-S", "-d=algnomem", "-o", filepath.Join(tmpdir, "p.o"), src)
+	cmd.Env = append(os.Environ(), "GOARCH=amd64", "GOOS=linux")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("compile failed: %v\n%s", err, output)
+	}
+
+	// Split the assembly listing into functions.
+	funcs := map[string]string{}
+	var name string
+	for _, line := range strings.Split(string(output), "\n") {
+		if f := strings.Fields(line); len(f) > 1 && f[1] == "STEXT" {
+			name = f[0]
+			continue
+		}
+		if name != "" {
+			funcs[name] += line + "\n"
+		}
+	}
+
+	for _, fn := range []string{"type:.eq.p.S5", "type:.eq.p.A9", "type:.hash.p.S5", "type:.hash.p.A9"} {
+		body, ok := funcs[fn]
+		if !ok {
+			t.Errorf("no %s in\n%s", fn, output)
+			continue
+		}
+		for _, call := range []string{"runtime.memequal(SB)", "runtime.memequal_varlen(SB)", "runtime.memhash(SB)", "runtime.memhash_varlen(SB)"} {
+			if strings.Contains(body, "CALL\t"+call) {
+				t.Errorf("%s calls %s under -d=algnomem:\n%s", fn, call, body)
+			}
+		}
+	}
+	if n := strings.Count(funcs["type:.hash.p.S5"], "CALL\truntime.memhash64(SB)"); n != 5 {
+		t.Errorf("type:.hash.p.S5 calls memhash64 %d times, want once per field:\n%s", n, funcs["type:.hash.p.S5"])
+	}
+}
@@ -204,10 +204,14 @@ func memequal16(x, y *any) bool
 func memequal32(x, y *any) bool
 func memequal64(x, y *any) bool
 func memequal128(x, y *any) bool
+func memequal256(x, y *any) bool
 func f32equal(p, q unsafe.Pointer) bool
 func f64equal(p, q unsafe.Pointer) bool
 func c64equal(p, q unsafe.Pointer) bool
 func c128equal(p, q unsafe.Pointer) bool
+func f32x4equal(p, q unsafe.Pointer) bool
+func f64x2equal(p, q unsafe.Pointer) bool
+func f64x4equal(p, q unsafe.Pointer) bool
 func strequal(p, q unsafe.Pointer) bool
 func interequal(p, q unsafe.Pointer) bool
 func nilinterequal(p, q unsafe.Pointer) bool
@@ -219,10 +223,14 @@ func memhash16(p unsafe.Pointer, h uintptr) uintptr
 func memhash32(p unsafe.Pointer, h uintptr) uintptr
 func memhash64(p unsafe.Pointer, h uintptr) uintptr
 func memhash128(p unsafe.Pointer, h uintptr) uintptr
+func memhash256(p unsafe.Pointer, h uintptr) uintptr
 func f32hash(p *any, h uintptr) uintptr
 func f64hash(p *any, h uintptr) uintptr
 func c64hash(p *any, h uintptr) uintptr
 func c128hash(p *any, h uintptr) uintptr
+func f32x4hash(p *any, h uintptr) uintptr
+func f64x2hash(p *any, h uintptr) uintptr
+func f64x4hash(p *any, h uintptr) uintptr
 func strhash(a *any, h uintptr) uintptr
 func interhash(p *any, h uintptr) uintptr
 func nilinterhash(p *any, h uintptr) uintptr
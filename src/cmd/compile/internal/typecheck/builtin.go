@@ -168,10 +168,14 @@ var runtimeDecls = [...]struct {
 	{"memequal32", funcTag, 126},
 	{"memequal64", funcTag, 126},
 	{"memequal128", funcTag, 126},
+	{"memequal256", funcTag, 126},
 	{"f32equal", funcTag, 127},
 	{"f64equal", funcTag, 127},
 	{"c64equal", funcTag, 127},
 	{"c128equal", funcTag, 127},
+	{"f32x4equal", funcTag, 127},
+	{"f64x2equal", funcTag, 127},
+	{"f64x4equal", funcTag, 127},
 	{"strequal", funcTag, 127},
 	{"interequal", funcTag, 127},
 	{"nilinterequal", funcTag, 127},
@@ -182,10 +186,14 @@ var runtimeDecls = [...]struct {
 	{"memhash32", funcTag, 129},
 	{"memhash64", funcTag, 129},
 	{"memhash128", funcTag, 129},
+	{"memhash256", funcTag, 129},
 	{"f32hash", funcTag, 130},
 	{"f64hash", funcTag, 130},
 	{"c64hash", funcTag, 130},
 	{"c128hash", funcTag, 130},
+	{"f32x4hash", funcTag, 130},
+	{"f64x2hash", funcTag, 130},
+	{"f64x4hash", funcTag, 130},
 	{"strhash", funcTag, 130},
 	{"interhash", funcTag, 130},
 	{"nilinterhash", funcTag, 130},
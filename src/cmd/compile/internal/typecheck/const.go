@@ -448,6 +448,88 @@ func IndexConst(n ir.Node) int64 {
 	return ir.IntVal(types.Types[types.TINT], v)
 }
 
+// ConstStructEqual reports whether two values of struct type t, given
+// as the constant values of their fields in field order, are equal. The
+// second result reports whether the comparison could be folded: t must
+// be comparable, every non-blank field must have a boolean, numeric, or
+// string type, whose alg compares values rather than representations,
+// and every field, blank or not, must have a constant value in both a
+// and b. Blank fields are not compared, as at run time, but a nil value
+// for one means its literal has an operand that must still be evaluated.
+func ConstStructEqual(a, b []constant.Value, t *types.Type) (equal, ok bool) {
+	if !t.IsStruct() || !types.IsComparable(t) || len(a) != t.NumFields() || len(b) != t.NumFields() {
+		return false, false
+	}
+	equal = true
+	for i, f := range t.Fields() {
+		if a[i] == nil || b[i] == nil {
+			return false, false
+		}
+		if f.Sym.IsBlank() {
+			continue
+		}
+		switch types.AlgType(f.Type) {
+		case types.AMEM:
+			if !f.Type.IsBoolean() && !f.Type.IsInteger() {
+				return false, false
+			}
+		case types.ASTRING, types.AFLOAT32, types.AFLOAT64, types.ACPLX64, types.ACPLX128:
+		default:
+			return false, false
+		}
+		if !constant.Compare(a[i], token.EQL, b[i]) {
+			equal = false
+		}
+	}
+	return equal, true
+}
+
+// structLitConsts returns the constant values of the fields of struct
+// literal n, in field order, or nil if n is not a struct literal, does
+// not set every field, or sets any field, blank fields included, to a
+// non-constant value. Folding a comparison of n must not drop the
+// evaluation of any of its operands.
+func structLitConsts(n ir.Node) []constant.Value {
+	if n.Op() != ir.OSTRUCTLIT {
+		return nil
+	}
+	lit := n.(*ir.CompLitExpr)
+	t := lit.Type()
+	if len(lit.List) != t.NumFields() {
+		return nil
+	}
+	vals := make([]constant.Value, t.NumFields())
+	for i, elt := range lit.List {
+		key := elt.(*ir.StructKeyExpr)
+		if key.Field != t.Field(i) {
+			return nil
+		}
+		if key.Value.Op() != ir.OLITERAL {
+			return nil
+		}
+		vals[i] = key.Value.Val()
+	}
+	return vals
+}
+
+// foldStructCompare returns the result of the == or != comparison n as
+// a constant, if both of its operands are struct literals with constant
+// fields that ConstStructEqual can compare. Otherwise it returns n.
+func foldStructCompare(n *ir.BinaryExpr) ir.Node {
+	if n.Op() != ir.OEQ && n.Op() != ir.ONE || !n.X.Type().IsStruct() {
+		return n
+	}
+	a, b := structLitConsts(n.X), structLitConsts(n.Y)
+	if a == nil || b == nil || !types.Identical(n.X.Type(), n.Y.Type()) {
+		return n
+	}
+	equal, ok := ConstStructEqual(a, b, n.X.Type())
+	if !ok {
+		return n
+	}
+	return ir.NewConstExpr(constant.MakeBool(equal == (n.Op() == ir.OEQ)), n)
+}
+
 // callOrChan reports whether n is a call or channel operation.
 func callOrChan(n ir.Node) bool {
 	switch n.Op() {
@@ -0,0 +1,79 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typecheck
+
+import (
+	"go/constant"
+	"testing"
+
+	"cmd/compile/internal/base"
+	"cmd/compile/internal/types"
+	"cmd/internal/obj"
+	"cmd/internal/src"
+	"cmd/internal/sys"
+)
+
+func init() {
+	types.PtrSize = 8
+	types.RegSize = 8
+	types.MaxWidth = 1 << 50
+	base.Ctxt = &obj.Link{Arch: &obj.LinkArch{Arch: &sys.Arch{Alignment: 1, CanMergeLoads: true}}}
+	InitUniverse()
+}
+
+func mkstruct(fieldTypes ...*types.Type) *types.Type {
+	fields := make([]*types.Field, len(fieldTypes))
+	for i, ftyp := range fieldTypes {
+		fields[i] = types.NewField(src.NoXPos, LookupNum("f", i), ftyp)
+	}
+	t := types.NewStruct(fields)
+	types.CalcSize(t)
+	return t
+}
+
+func TestConstStructEqual(t *testing.T) {
+	i := constant.MakeInt64
+	str := constant.MakeString
+	intT, strT, f64T := types.Types[types.TINT], types.Types[types.TSTRING], types.Types[types.TFLOAT64]
+	point := mkstruct(intT, intT)
+	mixed := mkstruct(strT, f64T, types.Types[types.TBOOL])
+	blank := types.NewStruct([]*types.Field{
+		types.NewField(src.NoXPos, LookupNum("f", 0), intT),
+		types.NewField(src.NoXPos, types.BlankSym, intT),
+	})
+	types.CalcSize(blank)
+
+	tests := []struct {
+		name      string
+		a, b      []constant.Value
+		typ       *types.Type
+		equal, ok bool
+	}{
+		{"Point{1, 2} == Point{1, 2}", []constant.Value{i(1), i(2)}, []constant.Value{i(1), i(2)}, point, true, true},
+		{"Point{1, 2} == Point{1, 3}", []constant.Value{i(1), i(2)}, []constant.Value{i(1), i(3)}, point, false, true},
+		{"non-constant field", []constant.Value{i(1), nil}, []constant.Value{i(1), i(2)}, point, false, false},
+		{"wrong field count", []constant.Value{i(1)}, []constant.Value{i(1)}, point, false, false},
+		{"mixed equal",
+			[]constant.Value{str("a"), constant.MakeFloat64(0.5), constant.MakeBool(true)},
+			[]constant.Value{str("a"), constant.MakeFloat64(0.5), constant.MakeBool(true)},
+			mixed, true, true},
+		{"mixed unequal",
+			[]constant.Value{str("a"), constant.MakeFloat64(0.5), constant.MakeBool(true)},
+			[]constant.Value{str("b"), constant.MakeFloat64(0.5), constant.MakeBool(true)},
+			mixed, false, true},
+		{"blank field not compared", []constant.Value{i(1), i(3)}, []constant.Value{i(1), i(7)}, blank, true, true},
+		{"non-constant blank field", []constant.Value{i(1), nil}, []constant.Value{i(1), i(7)}, blank, false, false},
+		{"pointer field", []constant.Value{nil}, []constant.Value{nil}, mkstruct(types.NewPtr(intT)), false, false},
+		{"nested struct field", []constant.Value{nil}, []constant.Value{nil}, mkstruct(point), false, false},
+		{"incomparable struct", []constant.Value{i(1), nil}, []constant.Value{i(1), nil}, mkstruct(intT, types.NewSlice(intT)), false, false},
+		{"non-struct", []constant.Value{i(1)}, []constant.Value{i(1)}, intT, false, false},
+	}
+	for _, tc := range tests {
+		equal, ok := ConstStructEqual(tc.a, tc.b, tc.typ)
+		if equal != tc.equal || ok != tc.ok {
+			t.Errorf("%s: ConstStructEqual = %v, %v, want %v, %v", tc.name, equal, ok, tc.equal, tc.ok)
+		}
+	}
+}
@@ -133,7 +133,7 @@ func tcArith(n ir.Node, op ir.Op, l, r ir.Node) (ir.Node, ir.Node, *types.Type)
 	}
 
 	if l.Type().IsSlice() && !ir.IsNil(l) && !ir.IsNil(r) {
-		base.Errorf("invalid operation: %v (slice can only be compared to nil)", n)
+		base.Errorf("invalid operation: %v (%s)", n, types.SliceCompareHint(l.Type()))
 		return l, r, nil
 	}
 
@@ -154,9 +154,71 @@ func tcArith(n ir.Node, op ir.Op, l, r ir.Node) (ir.Node, ir.Node, *types.Type)
 		}
 	}
 
+	if base.Debug.AlgNotes != 0 && n.Op().IsCmp() && !isAutogenerated(n.Pos()) && needsGeneratedAlg(l.Type()) {
+		base.WarnfAt(n.Pos(), "comparison of %v needs generated equality function: %s", l.Type(), types.SpecialReason(l.Type()))
+	}
+
 	return l, r, t
 }
 
+// needsGeneratedAlg reports whether values of type t are compared and
+// hashed by generated functions rather than runtime ones.
+func needsGeneratedAlg(t *types.Type) bool {
+	if !t.IsStruct() && !t.IsArray() {
+		return false
+	}
+	if _, ok := types.FloatVectorAlg(t); ok {
+		return false
+	}
+	return types.AlgType(t) == types.ASPECIAL
+}
+
+// isAutogenerated reports whether pos is in compiler-generated code,
+// including generated code imported from other packages for inlining.
+func isAutogenerated(pos src.XPos) bool {
+	return base.Ctxt.PosTable.Pos(pos).Filename() == base.Ctxt.PosTable.Pos(base.AutogeneratedPos).Filename()
+}
+
+// A mapKeyNote identifies a source line on which the notes for a map
+// key type have been reported.
+type mapKeyNote struct {
+	base *src.PosBase
+	line uint
+	key  *types.Type
+}
+
+// notedMapKeys records the lines already given notes by NoteMapKeyAlg.
+var notedMapKeys = map[mapKeyNote]bool{}
+
+// NoteMapKeyAlg reports, under -d=algnotes, that map type t needs
+// generated functions to hash and compare its keys, and under
+// -d=algpanic, that hashing its keys can panic. It is called wherever a
+// map type is used: by make and composite literals, and by the noder
+// for declared variables. Each key type is reported at most once per
+// line, so that m := make(map[K]V) gets a single note.
+func NoteMapKeyAlg(pos src.XPos, t *types.Type) {
+	if base.Debug.AlgNotes == 0 && base.Debug.AlgPanic == 0 || isAutogenerated(pos) {
+		return
+	}
+	key := t.Key()
+	p := base.Ctxt.PosTable.Pos(pos)
+	note := mapKeyNote{p.Base(), p.Line(), key}
+	if notedMapKeys[note] {
+		return
+	}
+	notedMapKeys[note] = true
+	if base.Debug.AlgNotes != 0 && needsGeneratedAlg(key) {
+		base.WarnfAt(pos, "map key type %v needs generated hash function: %s", key, types.SpecialReason(key))
+	}
+	if base.Debug.AlgPanic != 0 && !types.IsSelfComparable(key) {
+		if f := types.SelfCompareViolation(key); f != nil {
+			base.WarnfAt(pos, "map key type %v can panic when hashed: field %v holds an interface", key, f.Sym)
+		} else {
+			base.WarnfAt(pos, "map key type %v can panic when hashed: it holds an interface", key)
+		}
+	}
+}
+
 // The result of tcCompLit MUST be assigned back to n, e.g.
 //
 //	n.Left = tcCompLit(n.Left)
@@ -209,6 +271,7 @@ func tcCompLit(n *ir.CompLitExpr) (res ir.Node) {
 		}
 
 		n.SetOp(ir.OMAPLIT)
+		NoteMapKeyAlg(n.Pos(), t)
 
 	case types.TSTRUCT:
 		// Need valid field offsets for Xoffset below.
@@ -590,6 +590,7 @@ func tcMake(n *ir.CallExpr) ir.Node {
 		}
 		nn = ir.NewMakeExpr(n.Pos(), ir.OMAKEMAP, l, nil)
 		nn.SetEsc(n.Esc())
+		NoteMapKeyAlg(n.Pos(), t)
 
 	case types.TCHAN:
 		l = nil
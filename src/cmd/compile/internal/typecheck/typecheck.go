@@ -311,6 +311,7 @@ func typecheck1(n ir.Node, top int) ir.Node {
 			n.X, n.Y = l, r
 			n.SetType(types.UntypedBool)
 			n.X, n.Y = defaultlit2(l, r, true)
+			return foldStructCompare(n)
 		}
 		return n
 
@@ -4,7 +4,13 @@
 
 package types
 
-import "cmd/compile/internal/base"
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cmd/compile/internal/base"
+)
 
 // AlgKind describes the kind of algorithms used for comparing and
 // hashing a Type.
@@ -23,6 +29,7 @@ const (
 	AMEM32
 	AMEM64
 	AMEM128
+	AMEM256
 	ASTRING
 	AINTER
 	ANILINTER
@@ -30,6 +37,9 @@ const (
 	AFLOAT64
 	ACPLX64
 	ACPLX128
+	AFLOAT32x4 // Specific subvariants of ASPECIAL for small float arrays (see ../reflectdata).
+	AFLOAT64x2
+	AFLOAT64x4
 	ASPECIAL // Type needs special comparison/hashing functions.
 )
 
@@ -37,6 +47,74 @@ const (
 // the higher priority kinds override lower priority kinds.
 var algPriority = [ASPECIAL + 1]int8{ASPECIAL: 1, ANOEQ: 2, ANOALG: 3, AMEM: -1}
 
+// algCost orders the kinds by the expected cost of comparing two values,
+// independent of the order in which the kinds are declared:
+//
+//   - ANOEQ and ANOALG are cheapest, since any comparison is rejected
+//     at compile time;
+//   - AMEM0 compares nothing, its fixed-width siblings compare a single
+//     word or a few words, and general AMEM calls memequal;
+//   - float, complex, and float vector kinds compare a few values with
+//     float semantics;
+//   - ASTRING compares lengths and then contents;
+//   - interfaces compare type words and then call the dynamic type's
+//     equality function;
+//   - ASPECIAL needs a generated function and is the most expensive.
+//
+// Every kind other than AUNK must have a nonzero cost.
+var algCost = [ASPECIAL + 1]int8{
+	ANOEQ:      1,
+	ANOALG:     1,
+	AMEM0:      2,
+	AMEM8:      3,
+	AMEM16:     3,
+	AMEM32:     3,
+	AMEM64:     3,
+	AMEM128:    4,
+	AMEM256:    4,
+	AMEM:       5,
+	AFLOAT32:   6,
+	AFLOAT64:   6,
+	ACPLX64:    7,
+	ACPLX128:   7,
+	AFLOAT32x4: 7,
+	AFLOAT64x2: 7,
+	AFLOAT64x4: 7,
+	ASTRING:    8,
+	AINTER:     9,
+	ANILINTER:  9,
+	ASPECIAL:   10,
+}
+
+// AlgKindLess reports whether comparing values of kind a is expected to
+// be cheaper than comparing values of kind b. See algCost for the cost
+// model.
+func AlgKindLess(a, b AlgKind) bool {
+	if algCost[a] == 0 || algCost[b] == 0 {
+		base.Fatalf("AlgKindLess(%v, %v) with unknown kind", a, b)
+	}
+	return algCost[a] < algCost[b]
+}
+
+// IsMem reports whether a is AMEM or one of its fixed-width variants.
+func (a AlgKind) IsMem() bool {
+	return a >= AMEM && a <= AMEM256
+}
+
+// IsSpecial reports whether a is ASPECIAL.
+func (a AlgKind) IsSpecial() bool {
+	return a == ASPECIAL
+}
+
+// NeedsGenerated reports whether values of kind a are compared and
+// hashed by compiler-generated functions rather than runtime helpers.
+// Only ASPECIAL does; note that reflectdata.AlgType may still select a
+// runtime helper for some types whose kind is ASPECIAL here, such as
+// small float arrays (see NeedsGeneratedHash).
+func (a AlgKind) NeedsGenerated() bool {
+	return a.IsSpecial()
+}
+
 // setAlg sets the algorithm type of t to a, if it is of higher
 // priority to the current algorithm type.
 func (t *Type) setAlg(a AlgKind) {
@@ -51,35 +129,871 @@ func (t *Type) setAlg(a AlgKind) {
 }
 
 // AlgType returns the AlgKind used for comparing and hashing Type t.
+// The kind is computed once by CalcSize along with t's size and alignment
+// and cached on t, so repeated calls do not walk t's components.
+// If t's layout cannot be computed yet, AlgType returns AUNK and caches
+// nothing, so a later call returns the kind for the final layout.
+// Once t's size is computed, AlgType only reads the cached kind, so the
+// backend can call it from concurrent compilation goroutines, during
+// which CalcSizeDisabled forbids computing new sizes.
+//
+// Type parameters never reach AlgType: generic code is compiled using
+// shape types, which are laid out, and so compared, like their
+// underlying types.
 func AlgType(t *Type) AlgKind {
 	CalcSize(t)
+	if base.Debug.AlgCheck != 0 {
+		checkAlgLayout(t)
+	}
+	if t.alg == AUNK {
+		// CalcSize could not lay t out yet, as in tests that run
+		// before PtrSize is set. Report and record nothing, so that
+		// the kind computed once the layout is final is the only one
+		// seen.
+		return AUNK
+	}
+	if base.Debug.Alg != 0 {
+		debugAlg(t)
+	}
+	if base.Debug.AlgJSON != "" {
+		recordAlg(t)
+	}
+	if base.Debug.AlgHash != 0 || base.Debug.AlgNoMem != 0 {
+		return overrideAlg(t)
+	}
 	return t.alg
 }
 
+// checkAlgLayout reports a fatal error if t is a struct type whose size
+// or field offsets have not been computed, as happens when AlgType is
+// called before CalcSize can do its work. Without the check, the padding
+// analysis would silently use the unfinished layout.
+func checkAlgLayout(t *Type) {
+	if !t.IsStruct() {
+		return
+	}
+	if !t.widthCalculated() {
+		base.Fatalf("AlgType: width of %v not calculated", t)
+	}
+	for _, f := range t.Fields() {
+		if f.Offset == BADWIDTH {
+			base.Fatalf("AlgType: offset of field %v in %v not calculated", f.Sym, t)
+		}
+	}
+}
+
+// AlgOverride maps AlgKinds to the kinds AlgType reports in their place
+// for struct types and, under -d=algnomem, array types. It is consulted
+// only under -d=alghash or -d=algnomem, both of which map AMEM to ASPECIAL
+// so that memory-comparable types use generated hash and equality
+// functions, and is empty, and ignored, in normal builds. The two flags
+// differ in what they cover: -d=alghash stress-tests the generated
+// functions of structs, including empty ones, while -d=algnomem is meant
+// for telling miscompiled memory comparisons apart from other bugs, and
+// so also covers arrays, but leaves zero-size types, which have nothing
+// to compare, alone.
+var AlgOverride = map[AlgKind]AlgKind{}
+
+// overrideAlg returns the AlgKind of t after applying AlgOverride.
+func overrideAlg(t *Type) AlgKind {
+	noMem := base.Debug.AlgNoMem != 0
+	if noMem && t.width == 0 {
+		return t.alg
+	}
+	if t.IsStruct() || noMem && t.IsArray() {
+		if a, ok := AlgOverride[t.alg]; ok {
+			return a
+		}
+	}
+	return t.alg
+}
+
+// InvalidateAlg discards the AlgKind cached for t, so that it is
+// recomputed by the next call to AlgType. Since the kind is computed by
+// CalcSize, this also discards t's size, alignment, and pointer layout,
+// along with any -d=algjson record of the old kind.
+//
+// Code that changes the layout of a type after its size has been
+// calculated must call InvalidateAlg before making the change; setFields
+// refuses to modify a struct whose width is already known. Only t itself
+// is invalidated: the caller is responsible for any types, such as
+// arrays, structs, or function signatures, whose layout was computed
+// from t's.
+func InvalidateAlg(t *Type) {
+	t.width = BADWIDTH
+	t.align = 0
+	t.alg = AUNK
+	t.ptrBytes = 0
+	t.intRegs = 0
+	t.floatRegs = 0
+	delete(algRecords, t)
+}
+
+// ResetAlgRecords discards the records collected under -d=algjson. The
+// compiler compiles a single unit per process and never calls it; code
+// that reuses the types package for several units, such as tests, must
+// call it between them so that records do not carry over. It does not
+// touch the kinds that CalcSize caches on each type.
+func ResetAlgRecords() {
+	algRecords = map[*Type]algRecord{}
+}
+
+// An AlgDesc describes the layout facts about a type that the runtime
+// needs to compare and hash its values.
+type AlgDesc struct {
+	Kind       AlgKind
+	Size       int64
+	Align      int64
+	HasPadding bool // t is a struct with padding; see HasPadding
+}
+
+// AlgDescriptor returns the AlgDesc for type t. All of its facts are
+// computed together by CalcSize, so this is equivalent to, but cheaper
+// than, querying each separately.
+func AlgDescriptor(t *Type) AlgDesc {
+	a := AlgType(t)
+	return AlgDesc{
+		Kind:       a,
+		Size:       t.width,
+		Align:      int64(t.align),
+		HasPadding: t.IsStruct() && HasPadding(t),
+	}
+}
+
+// CompleteAlg reports whether the AlgKind of t is final, that is,
+// whether t and every component that contributes to its algorithm have
+// been fully defined. AlgType must not be called on t, and its result
+// must not be cached, until CompleteAlg reports true.
+//
+// Pointer, channel, map, slice, and function types are always complete,
+// since their algorithms do not depend on their element types.
+func CompleteAlg(t *Type) bool {
+	if t.widthCalculated() {
+		return true
+	}
+	switch t.Kind() {
+	case TFORW, TANY:
+		return false
+	case TARRAY:
+		return CompleteAlg(t.Elem())
+	case TSTRUCT:
+		for _, f := range t.Fields() {
+			if !CompleteAlg(f.Type) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FloatVectorAlg reports whether t is a small array of floats or complex
+// numbers that can be compared and hashed element-wise by a shared
+// runtime helper instead of generated functions, and if so returns
+// AFLOAT32x4, AFLOAT64x2, or AFLOAT64x4. A complex number compares like
+// its two float components, so [2]complex64 uses the same helpers as
+// [4]float32, and [2]complex128 the same as [4]float64.
+func FloatVectorAlg(t *Type) (AlgKind, bool) {
+	if !t.IsArray() {
+		return AUNK, false
+	}
+	n := t.NumElem()
+	switch t.Elem().Kind() {
+	case TCOMPLEX64:
+		n *= 2
+		fallthrough
+	case TFLOAT32:
+		if n == 4 {
+			return AFLOAT32x4, true
+		}
+	case TCOMPLEX128:
+		n *= 2
+		fallthrough
+	case TFLOAT64:
+		switch n {
+		case 2:
+			return AFLOAT64x2, true
+		case 4:
+			return AFLOAT64x4, true
+		}
+	}
+	return AUNK, false
+}
+
+// UniformArrayAlg reports whether every element of array type t is
+// compared and hashed by a single algorithm that needs no generated
+// function, and if so returns that algorithm's kind. This is the case
+// when the element type is itself memory, a string, an interface, a
+// float or complex number, or a struct that reduces to one of those,
+// such as struct{ x string }. The generated functions for such arrays,
+// which are ASPECIAL unless the elements are memory, simply loop over
+// the elements using the element's runtime helper.
+func UniformArrayAlg(t *Type) (AlgKind, bool) {
+	if !t.IsArray() {
+		base.Fatalf("UniformArrayAlg called non-array %v", t)
+	}
+	switch a := AlgType(t.Elem()); a {
+	case ANOEQ, ANOALG, ASPECIAL:
+		return a, false
+	default:
+		return a, true
+	}
+}
+
+// AlgTypes returns the AlgKinds used for comparing and hashing each of
+// the types in ts. Because the kind of every type is cached once
+// computed, components shared between the types are analyzed only once.
+func AlgTypes(ts []*Type) []AlgKind {
+	algs := make([]AlgKind, len(ts))
+	for i, t := range ts {
+		algs[i] = AlgType(t)
+	}
+	return algs
+}
+
+// debugAlg reports the AlgKind chosen for named type t under -d=alg,
+// along with the reason a struct type needs special functions.
+func debugAlg(t *Type) {
+	pos := t.Pos()
+	if !pos.IsKnown() {
+		return
+	}
+	if t.alg.IsSpecial() && t.IsStruct() {
+		base.WarnfAt(pos, "alg %v: %v (%s)", t, t.alg, specialReason(t))
+		return
+	}
+	base.WarnfAt(pos, "alg %v: %v", t, t.alg)
+}
+
+// SpecialReason describes why ASPECIAL type t needs generated
+// comparison and hashing functions, for diagnostics.
+func SpecialReason(t *Type) string {
+	if t.IsArray() {
+		return fmt.Sprintf("element type %v has alg %v", t.Elem(), t.Elem().alg)
+	}
+	return specialReason(t)
+}
+
+// specialReason describes why struct type t needs special comparison
+// and hashing functions.
+func specialReason(t *Type) string {
+	fields := t.Fields()
+	if len(fields) == 1 && !fields[0].Sym.IsBlank() {
+		if fields[0].Type.alg.IsMem() {
+			return fmt.Sprintf("padding after field %v", fields[0].Sym)
+		}
+		return fmt.Sprintf("field %v has alg %v", fields[0].Sym, fields[0].Type.alg)
+	}
+	for i, f := range fields {
+		switch f.Type.alg {
+		case AMEM:
+			if f.Sym.IsBlank() {
+				return "blank field"
+			}
+			if IsPaddedField(t, i) {
+				return fmt.Sprintf("padding after field %v", f.Sym)
+			}
+		case ANOEQ, ANOALG:
+		default:
+			return fmt.Sprintf("non-memory field %v", f.Sym)
+		}
+	}
+	return "unknown"
+}
+
+// AlgString returns a short description of the algorithm used for
+// comparing and hashing values of type t, for debug output: the name of
+// its AlgKind, or for ASPECIAL types, "special:" followed by a tag naming
+// the first component that prevents a plain memory comparison, such as
+// "special:padding" or "special:string-field".
+func (t *Type) AlgString() string {
+	a := AlgType(t)
+	if a != ASPECIAL {
+		return a.String()
+	}
+	return "special:" + specialTag(t)
+}
+
+// specialTag returns the tag used by AlgString for ASPECIAL type t.
+func specialTag(t *Type) string {
+	if t.IsArray() {
+		return algTag(t.Elem().alg) + "-elem"
+	}
+	fields := t.Fields()
+	for i, f := range fields {
+		switch f.Type.alg {
+		case AMEM:
+			if f.Sym.IsBlank() {
+				return "blank"
+			}
+			if IsPaddedField(t, i) {
+				return "padding"
+			}
+		case ANOEQ, ANOALG:
+		default:
+			return algTag(f.Type.alg) + "-field"
+		}
+	}
+	return "unknown"
+}
+
+// algTag returns a one-word name for the class of AlgKind a.
+func algTag(a AlgKind) string {
+	switch a {
+	case ASTRING:
+		return "string"
+	case AINTER, ANILINTER:
+		return "interface"
+	case AFLOAT32, AFLOAT64, AFLOAT32x4, AFLOAT64x2, AFLOAT64x4:
+		return "float"
+	case ACPLX64, ACPLX128:
+		return "complex"
+	case ASPECIAL:
+		return "special"
+	}
+	if a.IsMem() {
+		return "mem"
+	}
+	return "unknown"
+}
+
 // TypeHasNoAlg reports whether t does not have any associated hash/eq
 // algorithms because t, or some component of t, is marked Noalg.
 func TypeHasNoAlg(t *Type) bool {
 	return AlgType(t) == ANOALG
 }
 
+// NoalgSource returns the component of t responsible for t having no
+// hash/eq algorithms: t itself if it is marked Noalg, otherwise the first
+// struct field or array element type that carries the mark. It returns
+// nil if TypeHasNoAlg(t) is false.
+func NoalgSource(t *Type) *Type {
+	for TypeHasNoAlg(t) {
+		if t.Noalg() {
+			return t
+		}
+		switch t.Kind() {
+		case TARRAY:
+			t = t.Elem()
+		case TSTRUCT:
+			var next *Type
+			for _, f := range t.Fields() {
+				if TypeHasNoAlg(f.Type) {
+					next = f.Type
+					break
+				}
+			}
+			if next == nil {
+				base.Fatalf("NoalgSource: no Noalg component in %v", t)
+			}
+			t = next
+		default:
+			base.Fatalf("NoalgSource: unexpected Noalg type %v", t)
+		}
+	}
+	return nil
+}
+
+// ConcreteInterfaceAlg returns the AlgKind for comparing values of
+// interface type t that are statically known to hold a value of the
+// non-interface type concrete. Such comparisons can use concrete's
+// algorithm instead of the generic interface algorithm.
+func ConcreteInterfaceAlg(t, concrete *Type) AlgKind {
+	if !t.IsInterface() {
+		base.Fatalf("ConcreteInterfaceAlg: %v is not an interface", t)
+	}
+	if concrete.IsInterface() {
+		base.Fatalf("ConcreteInterfaceAlg: %v is an interface", concrete)
+	}
+	return AlgType(concrete)
+}
+
+// MustPanicOnCompare reports whether comparing values of interface type t
+// that are statically known to hold a value of the non-interface type
+// concrete always panics at run time, because concrete is not comparable.
+func MustPanicOnCompare(t, concrete *Type) bool {
+	return t.IsInterface() && !concrete.IsInterface() && !IsComparable(concrete)
+}
+
+// CompareAlwaysFalse reports whether comparing two interface values
+// that are statically known to hold values of the non-interface types a
+// and b always yields false. That is the case exactly when a and b are
+// not identical: the type words differ, so the data words are never
+// compared and the comparison cannot panic, even if a or b is not
+// comparable.
+func CompareAlwaysFalse(a, b *Type) bool {
+	if a.IsInterface() || b.IsInterface() {
+		return false
+	}
+	return !Identical(a, b)
+}
+
+// InterfaceCompareKind returns the AlgKind for comparing values of
+// interface type t, ANILINTER for empty interfaces and AINTER for all
+// others, along with the runtime function that compares their data words
+// once their type words are known to be equal: efaceeq or ifaceeq.
+func InterfaceCompareKind(t *Type) (AlgKind, string) {
+	if !t.IsInterface() {
+		base.Fatalf("InterfaceCompareKind: %v is not an interface", t)
+	}
+	a := AlgType(t)
+	if a == ANILINTER {
+		return a, "efaceeq"
+	}
+	return a, "ifaceeq"
+}
+
 // IsComparable reports whether t is a comparable type.
+//
+// A type is hashable exactly when it is comparable, so there is no
+// separate hashability predicate. In particular, IsComparable is false
+// for ANOALG types, such as the map bucket types built by the compiler,
+// even if their layout would be comparable: they have neither an
+// equality nor a hash function.
+//
+// Constraint interfaces such as comparable never reach the compiler's
+// types: types2 checks constraints, and generic code is compiled for
+// shape types. If the name comparable does end up on a method-less
+// interface type, that type is a plain empty interface here: ANILINTER
+// and comparable, though not self-comparable.
 func IsComparable(t *Type) bool {
 	a := AlgType(t)
 	return a != ANOEQ && a != ANOALG
 }
 
+// NoEqReason returns the kind of component that makes type t ANOEQ:
+// "func", "map", or "slice". For array and struct types, it is the kind
+// of the first such component found in an element or field. The result
+// is "" if t is not ANOEQ.
+func NoEqReason(t *Type) string {
+	if AlgType(t) != ANOEQ {
+		return ""
+	}
+	for {
+		switch t.Kind() {
+		case TFUNC:
+			return "func"
+		case TMAP:
+			return "map"
+		case TSLICE:
+			return "slice"
+		case TARRAY:
+			t = t.Elem()
+		case TSTRUCT:
+			var next *Type
+			for _, f := range t.Fields() {
+				if AlgType(f.Type) == ANOEQ {
+					next = f.Type
+					break
+				}
+			}
+			if next == nil {
+				return ""
+			}
+			t = next
+		default:
+			return ""
+		}
+	}
+}
+
+// IsNilComparable reports whether values of type t can be compared to
+// nil: t is a pointer, unsafe.Pointer, func, map, slice, channel, or
+// interface type. Func, map, and slice types are ANOEQ, so their values
+// can be compared only to nil, not to each other.
+func IsNilComparable(t *Type) bool {
+	switch t.Kind() {
+	case TPTR, TUNSAFEPTR, TFUNC, TMAP, TSLICE, TCHAN, TINTER:
+		return true
+	}
+	return false
+}
+
+// IsComparableReason reports whether t is a comparable type, along with
+// the AlgKind that decided it. If t is an incomparable struct, it also
+// returns the offending field.
+// The field is only looked for once t is known to be incomparable.
+func IsComparableReason(t *Type) (bool, AlgKind, *Field) {
+	a := AlgType(t)
+	if IsComparable(t) {
+		return true, a, nil
+	}
+	if t.IsStruct() {
+		return false, a, IncomparableField(t)
+	}
+	return false, a, nil
+}
+
+// IsSelfComparable reports whether comparing two values of type t is
+// statically guaranteed not to panic. A comparable type can still panic
+// if it has interface components, since they may hold values of
+// incomparable dynamic types, so IsSelfComparable is false for them
+// even though IsComparable is true. Blank fields are never compared and
+// are ignored.
+func IsSelfComparable(t *Type) bool {
+	switch AlgType(t) {
+	case ANOEQ, ANOALG, AINTER, ANILINTER:
+		return false
+	case ASPECIAL:
+		switch {
+		case t.IsArray():
+			return IsSelfComparable(t.Elem())
+		case t.IsStruct():
+			for _, f := range t.Fields() {
+				if !f.Sym.IsBlank() && !IsSelfComparable(f.Type) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// SelfCompareViolation returns the innermost non-blank field of type t,
+// descending through array elements and struct fields, whose interface
+// components can make comparing or hashing values of t panic. The
+// result is nil if t is self-comparable, or if t is itself an interface
+// or an array of interfaces.
+func SelfCompareViolation(t *Type) *Field {
+	var last *Field
+	for {
+		for t.IsArray() {
+			t = t.Elem()
+		}
+		if !t.IsStruct() {
+			return last
+		}
+		var next *Field
+		for _, f := range t.Fields() {
+			if !f.Sym.IsBlank() && !IsSelfComparable(f.Type) {
+				next = f
+				break
+			}
+		}
+		if next == nil {
+			return last
+		}
+		last, t = next, next.Type
+	}
+}
+
+// noEqCost is the CompareCost of incomparable types. It is larger than
+// the cost of comparing any comparable type.
+const noEqCost = 1 << 30
+
+// CompareCost returns a rough estimate of the number of instructions
+// needed to compare two values of type t, for use by heuristics such as
+// inlining decisions. Memory types cost one per register-sized word;
+// floats one and complex numbers two; strings and interfaces a few for
+// the length or type word check and the call comparing the rest. The
+// cost of other arrays and structs is the sum of the costs of their
+// elements or non-blank fields. Incomparable types cost noEqCost.
+func CompareCost(t *Type) int {
+	switch a := AlgType(t); {
+	case a == ANOEQ || a == ANOALG:
+		return noEqCost
+	case a.IsMem():
+		return int((t.width + int64(RegSize) - 1) / int64(RegSize))
+	case a == AFLOAT32 || a == AFLOAT64:
+		return 1
+	case a == ACPLX64 || a == ACPLX128:
+		return 2
+	case a == ASTRING || a == AINTER || a == ANILINTER:
+		return 3
+	}
+	var cost int64
+	switch {
+	case t.IsArray():
+		cost = t.NumElem() * int64(CompareCost(t.Elem()))
+	case t.IsStruct():
+		for _, f := range t.Fields() {
+			if !f.Sym.IsBlank() {
+				cost += int64(CompareCost(f.Type))
+			}
+		}
+	default:
+		base.Fatalf("CompareCost: unexpected type %v", t)
+	}
+	if cost >= noEqCost {
+		return noEqCost - 1
+	}
+	return int(cost)
+}
+
+// IsMemComparable reports whether values of type t can be compared and
+// hashed as plain memory, using AMEM or one of its fixed-width variants.
+func IsMemComparable(t *Type) bool {
+	return AlgType(t).IsMem()
+}
+
+// maxFastCompareRegs is the most integer registers a memory type may
+// occupy for FitsInRegisters to report true.
+const maxFastCompareRegs = 2
+
+// IsZeroComparable reports whether a value of type t can be compared
+// against the zero value of t by checking that all of its bytes are zero.
+// This holds exactly for memory-comparable types: they have no padding,
+// and they contain no floating-point components, for which -0.0 == 0.0
+// although the two have different bit patterns.
+func IsZeroComparable(t *Type) bool {
+	return IsMemComparable(t)
+}
+
+// FitsInRegisters reports whether t is a memory type whose values occupy
+// at most a couple of integer registers, and no floating-point registers,
+// under the ABIInternal calling conventions. Two such values can be
+// compared with a few register compares rather than a call to memequal.
+func FitsInRegisters(t *Type) bool {
+	if !IsMemComparable(t) {
+		return false
+	}
+	i, f := t.Registers()
+	return f == 0 && i <= maxFastCompareRegs
+}
+
+// NeedsGeneratedHash reports whether hashing values of type t requires a
+// compiler-generated hash function. Memory types use the runtime's
+// memhash variants; strings, interfaces, floats, complex numbers, and
+// small float arrays use dedicated runtime hashers; and incomparable
+// types have no hash function at all.
+func NeedsGeneratedHash(t *Type) bool {
+	if !AlgType(t).NeedsGenerated() {
+		return false
+	}
+	_, vector := FloatVectorAlg(t)
+	return !vector
+}
+
+// NeedsGeneratedEq reports whether comparing values of type t requires a
+// compiler-generated equality function. Only ASPECIAL structs and arrays
+// do: memory types compare with memequal and its fixed-width variants,
+// strings, interfaces, floats, and complex numbers with their runtime
+// helpers, and incomparable types not at all. Small float arrays are
+// ASPECIAL but compare element-wise with f32x4equal and friends.
+func NeedsGeneratedEq(t *Type) bool {
+	if AlgType(t) != ASPECIAL {
+		return false
+	}
+	_, vector := FloatVectorAlg(t)
+	return !vector
+}
+
+// SliceCompareHint returns an explanation to include in errors about
+// comparing values of slice type t, or "" if t is not a slice type.
+// Slices are incomparable whatever their element type, which the
+// message states explicitly, and it points to the functions that
+// compare slice elements instead.
+func SliceCompareHint(t *Type) string {
+	if !t.IsSlice() {
+		return ""
+	}
+	if t.Elem().Kind() == TUINT8 {
+		return "slice can only be compared to nil, regardless of its element type; use bytes.Equal to compare elements"
+	}
+	return "slice can only be compared to nil, regardless of its element type; use slices.Equal to compare elements"
+}
+
+// SliceHeaderCompareKind returns the algorithm kind for comparing
+// values of slice type t, which is always ANOEQ: the language does not
+// compare slice headers (pointer, length and capacity), and comparing
+// elements is left to library functions. See SliceCompareHint.
+func SliceHeaderCompareKind(t *Type) AlgKind {
+	if !t.IsSlice() {
+		base.Fatalf("SliceHeaderCompareKind called on non-slice %v", t)
+	}
+	return ANOEQ
+}
+
+// SliceElemMemComparable reports whether the elements of slice type t
+// are memory-comparable, so that two slices of type t with equal lengths
+// hold equal elements exactly when their backing arrays are equal as
+// memory. Slices themselves remain incomparable; this is for lowering
+// calls like slices.Equal.
+func SliceElemMemComparable(t *Type) bool {
+	if !t.IsSlice() {
+		base.Fatalf("SliceElemMemComparable called on non-slice %v", t)
+	}
+	return IsMemComparable(t.Elem())
+}
+
 // IncomparableField returns an incomparable Field of struct Type t, if any.
 func IncomparableField(t *Type) *Field {
+	if path := IncomparableFieldPath(t); len(path) > 0 {
+		return path[0]
+	}
+	return nil
+}
+
+// AllIncomparableFields returns every incomparable Field of struct Type
+// t, in field order, for reporting all of them at once. Like
+// IncomparableField, it reports fields of t itself: an incomparable
+// field is not examined further to find which of its own components is
+// to blame. The result is nil if t is comparable.
+func AllIncomparableFields(t *Type) []*Field {
+	var fields []*Field
 	for _, f := range t.Fields() {
 		if !IsComparable(f.Type) {
-			return f
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// IncomparableFieldPath returns the chain of fields leading from struct
+// Type t down to the first incomparable leaf, descending through nested
+// struct fields and array element types. The result is nil if t is
+// comparable.
+func IncomparableFieldPath(t *Type) []*Field {
+	return fieldPath(t, func(t *Type) bool { return !IsComparable(t) })
+}
+
+// IncomparableArrayElem returns the incomparable type that makes array
+// type t incomparable, descending through nested array element types and,
+// for arrays of structs, through the fields reported by
+// IncomparableFieldPath. For example, it returns []int for [3][]int and
+// map[int]int for [3]struct{ m map[int]int }. The result is nil if t is
+// comparable.
+func IncomparableArrayElem(t *Type) *Type {
+	if !t.IsArray() {
+		base.Fatalf("IncomparableArrayElem called non-array %v", t)
+	}
+	if IsComparable(t) {
+		return nil
+	}
+	for {
+		switch {
+		case t.IsArray():
+			t = t.Elem()
+		case t.IsStruct():
+			path := IncomparableFieldPath(t)
+			if len(path) == 0 {
+				// The struct itself is marked Noalg.
+				return t
+			}
+			t = path[len(path)-1].Type
+		default:
+			return t
+		}
+	}
+}
+
+// MapKeyAlg returns the AlgKind of the key type of map type t.
+func MapKeyAlg(t *Type) AlgKind {
+	if !t.IsMap() {
+		base.Fatalf("MapKeyAlg called on non-map %v", t)
+	}
+	return AlgType(t.Key())
+}
+
+// KeyClass classifies the cost of hashing and comparing map keys.
+type KeyClass int8
+
+//go:generate stringer -type KeyClass -trimprefix KeyClass alg.go
+
+// Map key comparison classes, as returned by KeyCompareClass.
+const (
+	KeyClassMemSmall KeyClass = iota // memory, at most one register wide
+	KeyClassMemLarge                 // memory, wider than a register
+	KeyClassString                   // string
+	KeyClassSpecial                  // anything else, such as floats, interfaces, or ASPECIAL types
+)
+
+// KeyCompareClass returns the class of the cost of hashing and comparing
+// map keys of type t, which must be comparable, for choosing map layouts.
+func KeyCompareClass(t *Type) KeyClass {
+	switch a := AlgType(t); {
+	case a.IsMem():
+		if t.Size() <= int64(RegSize) {
+			return KeyClassMemSmall
 		}
+		return KeyClassMemLarge
+	case a == ASTRING:
+		return KeyClassString
+	}
+	return KeyClassSpecial
+}
+
+// MapValAlg returns the AlgKind of the element type of map type t. Unlike
+// keys, map values need not be comparable, so the result may be ANOEQ;
+// helpers such as maps.Equal that compare values must check for that.
+func MapValAlg(t *Type) AlgKind {
+	if !t.IsMap() {
+		base.Fatalf("MapValAlg called on non-map %v", t)
+	}
+	return AlgType(t.Elem())
+}
+
+// MapClearNeedsGC reports whether zeroing the values of map type t, as
+// clear and map deletion do, must inform the garbage collector. It is
+// just the pointer check t.Elem().HasPointers(): nothing is generated,
+// and the runtime clears such values with memclrHasPointers rather than
+// a plain memory clear. Values need not be comparable to be cleared.
+func MapClearNeedsGC(t *Type) bool {
+	if !t.IsMap() {
+		base.Fatalf("MapClearNeedsGC called on non-map %v", t)
+	}
+	return t.Elem().HasPointers()
+}
+
+// MapKeyViolation returns the field of map key type keyType that holds a
+// map, for explaining why keyType is not a valid map key. The field may
+// be in a struct nested within keyType, or within its array elements, and
+// its own type may be an array of maps. The result is nil if keyType
+// contains no map.
+func MapKeyViolation(keyType *Type) *Field {
+	if path := fieldPath(keyType, containsMap); len(path) > 0 {
+		return path[len(path)-1]
 	}
 	return nil
 }
 
+// containsMap reports whether t is a map type, or an array or struct
+// type with a map component.
+func containsMap(t *Type) bool {
+	switch t.Kind() {
+	case TMAP:
+		return true
+	case TARRAY:
+		return containsMap(t.Elem())
+	case TSTRUCT:
+		for _, f := range t.Fields() {
+			if containsMap(f.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fieldPath returns the chain of fields leading from struct Type t down
+// to a component for which has reports true, descending through nested
+// struct fields and array element types. At each level, it follows the
+// first field for whose type has reports true, so has must also report
+// true for any type with such a component.
+func fieldPath(t *Type, has func(*Type) bool) []*Field {
+	var path []*Field
+	for t.IsStruct() {
+		var next *Field
+		for _, f := range t.Fields() {
+			if has(f.Type) {
+				next = f
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		path = append(path, next)
+		t = next.Type
+		for t.IsArray() {
+			t = t.Elem()
+		}
+	}
+	return path
+}
+
 // IsPaddedField reports whether the i'th field of struct type t is followed
-// by padding.
+// by padding. For the last field, that is the padding at the end of t,
+// if any; see IsTailPadded.
 func IsPaddedField(t *Type, i int) bool {
 	if !t.IsStruct() {
 		base.Fatalf("IsPaddedField called non-struct %v", t)
@@ -90,3 +1004,450 @@ func IsPaddedField(t *Type, i int) bool {
 	}
 	return t.Field(i).End() != end
 }
+
+// A PadKind classifies the bytes that follow a struct field, as reported
+// by PaddingKind.
+type PadKind int8
+
+const (
+	PadNone          PadKind = iota // no bytes before the next non-blank field or the end of the struct
+	PadAlignment                    // at least one byte is alignment padding
+	PadTrailingBlank                // every byte belongs to following blank fields
+)
+
+// PaddingKind classifies the bytes between the end of the i'th field of
+// struct type t and the next non-blank field, or the end of t if there
+// is none. Neither kind of byte is compared, but only alignment padding
+// can be removed by reordering fields, so linters that suggest
+// reordering should act only on PadAlignment.
+func PaddingKind(t *Type, i int) PadKind {
+	if !t.IsStruct() {
+		base.Fatalf("PaddingKind called non-struct %v", t)
+	}
+	CalcSize(t)
+	fields := t.Fields()
+	kind := PadNone
+	end := fields[i].End()
+	for j := i + 1; ; j++ {
+		next := t.width
+		if j < len(fields) {
+			next = fields[j].Offset
+		}
+		if next != end {
+			return PadAlignment
+		}
+		if j == len(fields) || !fields[j].Sym.IsBlank() {
+			return kind
+		}
+		if fields[j].Type.Size() != 0 {
+			kind = PadTrailingBlank
+		}
+		end = fields[j].End()
+	}
+}
+
+// IsTailPadded reports whether struct type t has padding after its last
+// field, as opposed to padding only between fields.
+func IsTailPadded(t *Type) bool {
+	if !t.IsStruct() {
+		base.Fatalf("IsTailPadded called non-struct %v", t)
+	}
+	CalcSize(t)
+	n := t.NumFields()
+	if n == 0 {
+		return t.width != 0
+	}
+	return IsPaddedField(t, n-1)
+}
+
+// PaddingBytes returns the total number of padding bytes in struct type t,
+// both between fields and after the last field.
+func PaddingBytes(t *Type) int64 {
+	if !t.IsStruct() {
+		base.Fatalf("PaddingBytes called non-struct %v", t)
+	}
+	CalcSize(t)
+	fields := t.Fields()
+	if len(fields) == 0 {
+		return t.width
+	}
+	var pad int64
+	for i, f := range fields {
+		end := t.width
+		if i+1 < len(fields) {
+			end = fields[i+1].Offset
+		}
+		pad += end - f.End()
+	}
+	return pad
+}
+
+// HasPadding reports whether struct type t contains any padding, either
+// between fields or after the last field.
+func HasPadding(t *Type) bool {
+	if !t.IsStruct() {
+		base.Fatalf("HasPadding called non-struct %v", t)
+	}
+	fields := t.Fields()
+	end := int64(0)
+	for _, f := range fields {
+		if f.Offset != end {
+			return true
+		}
+		end = f.End()
+	}
+	return end != t.width
+}
+
+// NeedsPaddingZeroed reports whether values of type t contain padding
+// bytes, in t itself or in any of its struct fields or array elements.
+// Padding contents are unspecified, so hashing or comparing such values
+// as plain memory would need the padding zeroed first. The compiler never
+// does that: instead, CalcStructSize makes every padded struct ASPECIAL,
+// so generated functions hash and compare it field by field. Hence a
+// comparable type for which NeedsPaddingZeroed is true is always ASPECIAL.
+func NeedsPaddingZeroed(t *Type) bool {
+	CalcSize(t)
+	switch {
+	case t.IsArray():
+		return t.NumElem() > 0 && NeedsPaddingZeroed(t.Elem())
+	case t.IsStruct():
+		if HasPadding(t) {
+			return true
+		}
+		for _, f := range t.Fields() {
+			if NeedsPaddingZeroed(f.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ContainsUnsafePtr reports whether values of type t contain an
+// unsafe.Pointer, either directly or in an array element or struct
+// field. unsafe.Pointer values compare as plain memory, like other
+// pointers, so this does not affect t's AlgKind.
+func ContainsUnsafePtr(t *Type) bool {
+	switch t.Kind() {
+	case TUNSAFEPTR:
+		return true
+	case TARRAY:
+		return t.NumElem() > 0 && ContainsUnsafePtr(t.Elem())
+	case TSTRUCT:
+		for _, f := range t.Fields() {
+			if ContainsUnsafePtr(f.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ComparableComponents splits the fields of struct type t into those that
+// can be compared as plain memory and those that need special handling.
+// Blank fields, fields followed by padding, and fields whose own
+// algorithm is not AMEM are all special.
+func ComparableComponents(t *Type) (memFields, specialFields []*Field) {
+	if !t.IsStruct() {
+		base.Fatalf("ComparableComponents called non-struct %v", t)
+	}
+	CalcSize(t)
+	for i, f := range t.Fields() {
+		if AlgType(f.Type).IsMem() && !f.Sym.IsBlank() && !IsPaddedField(t, i) {
+			memFields = append(memFields, f)
+		} else {
+			specialFields = append(specialFields, f)
+		}
+	}
+	return memFields, specialFields
+}
+
+// A MemRun is a range of bytes within a struct that can be compared
+// and hashed as plain memory.
+type MemRun struct {
+	Offset, Len int64
+}
+
+// MemCompareRuns returns the maximal runs of contiguous, unpadded,
+// non-blank AMEM fields of struct type t, in field order. Fields outside
+// the runs need special comparison, or none at all if they are blank.
+func MemCompareRuns(t *Type) []MemRun {
+	if !t.IsStruct() {
+		base.Fatalf("MemCompareRuns called non-struct %v", t)
+	}
+	CalcSize(t)
+	isMem := func(f *Field) bool {
+		return !f.Sym.IsBlank() && AlgType(f.Type).IsMem()
+	}
+	var runs []MemRun
+	fields := t.Fields()
+	for i := 0; i < len(fields); {
+		if !isMem(fields[i]) {
+			i++
+			continue
+		}
+		start := fields[i].Offset
+		for i++; i < len(fields) && !IsPaddedField(t, i-1) && isMem(fields[i]); i++ {
+		}
+		if n := fields[i-1].End() - start; n > 0 {
+			runs = append(runs, MemRun{Offset: start, Len: n})
+		}
+	}
+	return runs
+}
+
+// FirstMemField returns the first run reported by MemCompareRuns for
+// struct type t, provided it starts at the first non-blank field of t.
+// Comparing that run first lets generated equality functions reject
+// unequal values cheaply before comparing the rest. ok is false if t has
+// no non-blank fields, or if its first one is zero-sized or not AMEM,
+// such as a string or interface.
+func FirstMemField(t *Type) (offset, length int64, ok bool) {
+	if !t.IsStruct() {
+		base.Fatalf("FirstMemField called non-struct %v", t)
+	}
+	for _, f := range t.Fields() {
+		if f.Sym.IsBlank() {
+			continue
+		}
+		if !AlgType(f.Type).IsMem() || f.Type.Size() == 0 {
+			return 0, 0, false
+		}
+		r := MemCompareRuns(t)[0]
+		return r.Offset, r.Len, true
+	}
+	return 0, 0, false
+}
+
+// CanReduceToMemCompare reports whether values of ASPECIAL struct type t
+// can be compared with a single memory comparison of length bytes at
+// offset, because all of its non-blank fields are AMEM and lie in a
+// single run as reported by MemCompareRuns. Blank fields and padding
+// outside the run are ignored, as they never take part in comparison.
+func CanReduceToMemCompare(t *Type) (ok bool, offset, length int64) {
+	if !t.IsStruct() || AlgType(t) != ASPECIAL {
+		return false, 0, 0
+	}
+	for _, f := range t.Fields() {
+		if !f.Sym.IsBlank() && !AlgType(f.Type).IsMem() {
+			return false, 0, 0
+		}
+	}
+	runs := MemCompareRuns(t)
+	if len(runs) != 1 {
+		return false, 0, 0
+	}
+	return true, runs[0].Offset, runs[0].Len
+}
+
+// DiscriminantField returns the first non-blank field of struct type t
+// with a small integer type, one or two bytes wide, as a candidate tag for
+// tools examining union-like structs, whose tag selects which of the other
+// fields are in use. It is only a heuristic: the compiler itself still
+// compares and hashes all fields. The result is nil if there is no such
+// field.
+func DiscriminantField(t *Type) *Field {
+	if !t.IsStruct() {
+		base.Fatalf("DiscriminantField called non-struct %v", t)
+	}
+	CalcSize(t)
+	for _, f := range t.Fields() {
+		if !f.Sym.IsBlank() && f.Type.IsInteger() && f.Type.Size() <= 2 {
+			return f
+		}
+	}
+	return nil
+}
+
+// SuggestReorder returns copies of the fields of struct type t, reordered
+// by descending alignment to minimize padding. Blank fields keep their
+// positions, and fields are only reordered between them. t itself is not
+// modified.
+func SuggestReorder(t *Type) []*Field {
+	if !t.IsStruct() {
+		base.Fatalf("SuggestReorder called non-struct %v", t)
+	}
+	CalcSize(t)
+	fields := make([]*Field, t.NumFields())
+	for i, f := range t.Fields() {
+		fields[i] = f.Copy()
+	}
+	byAlign := func(s []*Field) {
+		sort.SliceStable(s, func(i, j int) bool {
+			return s[i].Type.Alignment() > s[j].Type.Alignment()
+		})
+	}
+	start := 0
+	for i, f := range fields {
+		if f.Sym.IsBlank() {
+			byAlign(fields[start:i])
+			start = i + 1
+		}
+	}
+	byAlign(fields[start:])
+	return fields
+}
+
+// EqualAlg reports whether values of types a and b can be compared and
+// hashed by the same functions. Both types must have the same AlgKind and
+// size. For ASPECIAL types, the layouts must also match: array types must
+// have the same length and compatible element types, and struct types
+// must have the same number of fields, at the same offsets, with the same
+// blank fields and compatible field types.
+func EqualAlg(a, b *Type) bool {
+	if a == b {
+		return true
+	}
+	if AlgType(a) != AlgType(b) || a.Size() != b.Size() {
+		return false
+	}
+	if !a.alg.IsSpecial() {
+		return true
+	}
+	switch {
+	case a.IsArray() && b.IsArray():
+		return a.NumElem() == b.NumElem() && EqualAlg(a.Elem(), b.Elem())
+	case a.IsStruct() && b.IsStruct():
+		af, bf := a.Fields(), b.Fields()
+		if len(af) != len(bf) {
+			return false
+		}
+		for i := range af {
+			if af[i].Offset != bf[i].Offset || af[i].Sym.IsBlank() != bf[i].Sym.IsBlank() {
+				return false
+			}
+			if !EqualAlg(af[i].Type, bf[i].Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// AlgSignature returns a string describing how values of type t are
+// compared and hashed, such that types with equal signatures can share
+// generated functions. The signature records t's AlgKind and size and,
+// for ASPECIAL types, the layout: the length and element signature of an
+// array, or the offset and signature of every struct field, with blank
+// fields marked. It depends only on that layout, not on the names of t or
+// its fields, so it is stable across compilations and packages, and
+// structs that differ only in padding have different signatures.
+func AlgSignature(t *Type) string {
+	var b strings.Builder
+	writeAlgSignature(&b, t)
+	return b.String()
+}
+
+func writeAlgSignature(b *strings.Builder, t *Type) {
+	a := AlgType(t)
+	if a != ASPECIAL {
+		fmt.Fprintf(b, "%v%d", a, t.Size())
+		return
+	}
+	switch {
+	case t.IsArray():
+		fmt.Fprintf(b, "[%d]", t.NumElem())
+		writeAlgSignature(b, t.Elem())
+	case t.IsStruct():
+		b.WriteString("struct{")
+		for i, f := range t.Fields() {
+			if i > 0 {
+				b.WriteByte(';')
+			}
+			fmt.Fprintf(b, "%d:", f.Offset)
+			if f.Sym.IsBlank() {
+				fmt.Fprintf(b, "_%d", f.Type.Size())
+				continue
+			}
+			writeAlgSignature(b, f.Type)
+		}
+		fmt.Fprintf(b, "}%d", t.Size())
+	}
+}
+
+// AlgCompatibleForCompare reports whether the operands of a comparison,
+// of types a and b, can be compared with a single algorithm, as the
+// back end requires. Typechecking converts the operands of comparisons
+// between distinct types, such as a defined type and its underlying type,
+// to a common type, so this always holds for well-typed comparisons; walk
+// checks it to catch typechecking bugs.
+func AlgCompatibleForCompare(a, b *Type) bool {
+	return EqualAlg(a, b)
+}
+
+// CanShareAlg reports whether values of all the types in ts, such as the
+// type arguments of several instantiations of a generic function, can be
+// compared and hashed by a single pair of generated functions, and if so,
+// returns their common AlgKind. For ASPECIAL types this requires the
+// layouts to match as described for EqualAlg, which in particular means
+// that struct types have identical MemCompareRuns. An empty ts shares
+// nothing.
+func CanShareAlg(ts []*Type) (bool, AlgKind) {
+	if len(ts) == 0 {
+		return false, AUNK
+	}
+	for _, t := range ts[1:] {
+		if !EqualAlg(ts[0], t) {
+			return false, AUNK
+		}
+	}
+	return true, AlgType(ts[0])
+}
+
+// ShareEqClosure reports whether a single generated equality function
+// can compare values of type a and values of type b, so that the closure
+// emitted for one can serve for the other. Only ASPECIAL types have
+// generated equality functions; other kinds use runtime functions and
+// have no closure to share. Two ASPECIAL types share a closure when
+// their AlgSignatures are equal.
+func ShareEqClosure(a, b *Type) bool {
+	if AlgType(a) != ASPECIAL || AlgType(b) != ASPECIAL {
+		return false
+	}
+	return AlgSignature(a) == AlgSignature(b)
+}
+
+// WalkAlg calls fn for each comparison unit of type t, in memory order:
+// each component that is compared with a single algorithm rather than by
+// comparing its own components in turn. WalkAlg descends into array
+// elements and struct fields whose AlgKind is ASPECIAL; every other
+// component, including t itself if it is not ASPECIAL, is a unit.
+//
+// For each unit, path is the sequence of struct fields leading to it
+// from t, and elemIndex is its index within the innermost enclosing
+// array, or -1 if there is none. Blank fields are never compared, but
+// are still visited, as single units, so that callers see the full
+// layout; callers recognize them by path[len(path)-1].Sym.IsBlank().
+// Zero-length arrays have no units. fn must not retain path.
+func WalkAlg(t *Type, fn func(path []*Field, elemIndex int, a AlgKind)) {
+	walkAlg(t, nil, -1, fn)
+}
+
+func walkAlg(t *Type, path []*Field, elemIndex int, fn func([]*Field, int, AlgKind)) {
+	if t.IsArray() && t.NumElem() == 0 {
+		return
+	}
+	a := AlgType(t)
+	if a != ASPECIAL {
+		fn(path, elemIndex, a)
+		return
+	}
+	switch {
+	case t.IsArray():
+		for i := int64(0); i < t.NumElem(); i++ {
+			walkAlg(t.Elem(), path, int(i), fn)
+		}
+	case t.IsStruct():
+		for _, f := range t.Fields() {
+			if f.Sym.IsBlank() {
+				fn(append(path, f), elemIndex, AlgType(f.Type))
+				continue
+			}
+			walkAlg(f.Type, append(path, f), elemIndex, fn)
+		}
+	default:
+		fn(path, elemIndex, a)
+	}
+}
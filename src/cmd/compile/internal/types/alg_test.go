@@ -0,0 +1,2533 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"cmd/compile/internal/base"
+	"cmd/internal/src"
+)
+
+type testObj struct {
+	sym *Sym
+	typ *Type
+}
+
+func (o *testObj) Pos() src.XPos { return src.NoXPos }
+func (o *testObj) Sym() *Sym     { return o.sym }
+func (o *testObj) Type() *Type   { return o.typ }
+
+func init() {
+	// These are the few constants that need to be initialized in order to
+	// compute sizes and algorithms of types without the typecheck package.
+	PtrSize = 8
+	RegSize = 8
+	MaxWidth = 1 << 50
+	LocalPkg = NewPkg("p", "")
+	BlankSym = LocalPkg.Lookup("_")
+	InitTypes(func(sym *Sym, typ *Type) Object {
+		return &testObj{sym: sym, typ: typ}
+	})
+}
+
+// mkstruct returns a struct type with fields named f0, f1, ... of the
+// given types.
+func mkstruct(fieldTypes ...*Type) *Type {
+	fields := make([]*Field, len(fieldTypes))
+	for i, ftyp := range fieldTypes {
+		fields[i] = NewField(src.NoXPos, LocalPkg.LookupNum("f", i), ftyp)
+	}
+	t := NewStruct(fields)
+	CalcSize(t)
+	return t
+}
+
+func TestIncomparableFieldPath(t *testing.T) {
+	slice := NewSlice(Types[TINT])
+	inner := mkstruct(Types[TINT], slice)
+	middle := mkstruct(Types[TSTRING], NewArray(inner, 2))
+	outer := mkstruct(Types[TINT64], middle)
+
+	path := IncomparableFieldPath(outer)
+	want := []*Field{outer.Field(1), middle.Field(1), inner.Field(1)}
+	if len(path) != len(want) {
+		t.Fatalf("IncomparableFieldPath(outer) has %d fields, want %d", len(path), len(want))
+	}
+	for i := range want {
+		if path[i] != want[i] {
+			t.Errorf("IncomparableFieldPath(outer)[%d] = %v, want %v", i, path[i].Sym, want[i].Sym)
+		}
+	}
+	if f := IncomparableField(outer); f != outer.Field(1) {
+		t.Errorf("IncomparableField(outer) = %v, want %v", f.Sym, outer.Field(1).Sym)
+	}
+
+	comparable := mkstruct(Types[TINT], Types[TSTRING])
+	if path := IncomparableFieldPath(comparable); path != nil {
+		t.Errorf("IncomparableFieldPath(comparable) = %v, want nil", path)
+	}
+}
+
+func TestAllIncomparableFields(t *testing.T) {
+	m := NewMap(Types[TINT], Types[TINT])
+	fn := NewSignature(nil, nil, nil)
+	inner := mkstruct(NewSlice(Types[TINT]), m)
+	// struct{ f0 int; f1 map[int]int; f2 string; f3 func(); f4 inner }
+	typ := mkstruct(Types[TINT], m, Types[TSTRING], fn, inner)
+
+	got := AllIncomparableFields(typ)
+	want := []*Field{typ.Field(1), typ.Field(3), typ.Field(4)}
+	if len(got) != len(want) {
+		t.Fatalf("AllIncomparableFields has %d fields, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("AllIncomparableFields[%d] = %v, want %v", i, got[i].Sym, want[i].Sym)
+		}
+	}
+	if f := IncomparableField(typ); f != got[0] {
+		t.Errorf("IncomparableField = %v, want %v", f.Sym, got[0].Sym)
+	}
+
+	if got := AllIncomparableFields(mkstruct(Types[TINT], Types[TSTRING])); got != nil {
+		t.Errorf("AllIncomparableFields(comparable) = %v, want nil", got)
+	}
+}
+
+// nestedStruct returns a struct type nested depth levels deep, with each
+// level holding two copies of the level below it.
+func nestedStruct(depth int) *Type {
+	t := mkstruct(Types[TINT64], Types[TSTRING])
+	for i := 0; i < depth; i++ {
+		t = mkstruct(t, NewArray(t, 2))
+	}
+	return t
+}
+
+func BenchmarkAlgTypeNested(b *testing.B) {
+	t := nestedStruct(16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if AlgType(t) != ASPECIAL {
+			b.Fatal("unexpected alg")
+		}
+	}
+}
+
+func TestIsComparableReason(t *testing.T) {
+	fn := NewSignature(nil, nil, nil)
+	m := NewMap(Types[TINT], Types[TINT])
+	slice := NewSlice(Types[TINT])
+	withMap := mkstruct(Types[TINT], m)
+
+	tests := []struct {
+		name  string
+		typ   *Type
+		ok    bool
+		alg   AlgKind
+		field *Field
+	}{
+		{"int", Types[TINT], true, AMEM, nil},
+		{"func", fn, false, ANOEQ, nil},
+		{"map", m, false, ANOEQ, nil},
+		{"slice", slice, false, ANOEQ, nil},
+		{"struct with map", withMap, false, ANOEQ, withMap.Field(1)},
+	}
+	for _, tc := range tests {
+		ok, alg, field := IsComparableReason(tc.typ)
+		if ok != tc.ok || alg != tc.alg || field != tc.field {
+			t.Errorf("IsComparableReason(%s) = %v, %v, %v; want %v, %v, %v", tc.name, ok, alg, field, tc.ok, tc.alg, tc.field)
+		}
+		if IsComparable(tc.typ) != tc.ok {
+			t.Errorf("IsComparable(%s) = %v, want %v", tc.name, !tc.ok, tc.ok)
+		}
+	}
+}
+
+func TestNoalgSource(t *testing.T) {
+	marked := NewStruct([]*Field{NewField(src.NoXPos, LocalPkg.Lookup("x"), Types[TINT])})
+	marked.SetNoalg(true)
+	CalcSize(marked)
+
+	embed := mkstruct(Types[TSTRING], marked)
+	array := NewArray(marked, 4)
+
+	tests := []struct {
+		name string
+		typ  *Type
+		want *Type
+	}{
+		{"marked", marked, marked},
+		{"struct embedding marked", embed, marked},
+		{"array of marked", array, marked},
+		{"struct of array of marked", mkstruct(array), marked},
+		{"int", Types[TINT], nil},
+		{"map", NewMap(Types[TINT], Types[TINT]), nil},
+	}
+	for _, tc := range tests {
+		if got := NoalgSource(tc.typ); got != tc.want {
+			t.Errorf("NoalgSource(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAlgTypeArrayOfMem(t *testing.T) {
+	wrapped := mkstruct(Types[TUINT64])
+	tests := []struct {
+		name string
+		typ  *Type
+	}{
+		{"[3]struct{x uint64}", NewArray(wrapped, 3)},
+		{"[8]struct{x uint64}", NewArray(wrapped, 8)},
+		{"[5]byte", NewArray(Types[TUINT8], 5)},
+		{"[3]uint16", NewArray(Types[TUINT16], 3)},
+		{"[7]struct{x [3]byte}", NewArray(mkstruct(NewArray(Types[TUINT8], 3)), 7)},
+	}
+	for _, tc := range tests {
+		if got := AlgType(tc.typ); got != AMEM {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, AMEM)
+		}
+	}
+}
+
+func TestConcreteInterfaceAlg(t *testing.T) {
+	pair := mkstruct(Types[TINT64], Types[TSTRING])
+	for _, iface := range []*Type{Types[TINTER], ErrorType} {
+		tests := []struct {
+			concrete *Type
+			want     AlgKind
+		}{
+			{Types[TINT], AMEM},
+			{Types[TSTRING], ASTRING},
+			{pair, ASPECIAL},
+		}
+		for _, tc := range tests {
+			if got := ConcreteInterfaceAlg(iface, tc.concrete); got != tc.want {
+				t.Errorf("ConcreteInterfaceAlg(%v, %v) = %v, want %v", iface, tc.concrete, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestCompareAlwaysFalse(t *testing.T) {
+	slice := NewSlice(Types[TINT])
+	tests := []struct {
+		a, b *Type
+		want bool
+	}{
+		{Types[TINT], Types[TSTRING], true},
+		{Types[TINT], Types[TINT64], true},
+		{Types[TINT], slice, true},
+		{Types[TINT], Types[TINT], false},
+		{NewSlice(Types[TINT]), slice, false},
+		{mkstruct(Types[TINT]), mkstruct(Types[TINT]), false},
+		{Types[TINT], Types[TINTER], false},
+	}
+	for _, tc := range tests {
+		if got := CompareAlwaysFalse(tc.a, tc.b); got != tc.want {
+			t.Errorf("CompareAlwaysFalse(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestMustPanicOnCompare(t *testing.T) {
+	slice := NewSlice(Types[TINT])
+	tests := []struct {
+		iface, concrete *Type
+		want            bool
+	}{
+		{Types[TINTER], slice, true},
+		{Types[TINTER], NewMap(Types[TINT], Types[TINT]), true},
+		{Types[TINTER], NewSignature(nil, nil, nil), true},
+		{Types[TINTER], mkstruct(Types[TINT], slice), true},
+		{Types[TINTER], Types[TINT], false},
+		{ErrorType, Types[TINT], false},
+		{Types[TINT], slice, false},
+	}
+	for _, tc := range tests {
+		if got := MustPanicOnCompare(tc.iface, tc.concrete); got != tc.want {
+			t.Errorf("MustPanicOnCompare(%v, %v) = %v, want %v", tc.iface, tc.concrete, got, tc.want)
+		}
+	}
+}
+
+// mkshape returns a shape type with the given underlying type, as used
+// for instantiating generic code.
+func mkshape(underlying *Type) *Type {
+	sym := ShapePkg.Lookup(underlying.LinkString())
+	obj := &testObj{sym: sym}
+	t := NewNamed(obj)
+	obj.typ = t
+	t.SetUnderlying(underlying)
+	CalcSize(t)
+	return t
+}
+
+func TestAlgTypeShape(t *testing.T) {
+	tests := []struct {
+		underlying *Type
+		want       AlgKind
+	}{
+		{Types[TINT], AMEM},
+		{Types[TSTRING], ASTRING},
+		{mkstruct(Types[TINT64], Types[TINT64]), AMEM},
+		{mkstruct(Types[TINT64], Types[TSTRING]), ASPECIAL},
+		{mkstruct(Types[TINT64], NewSlice(Types[TINT])), ANOEQ},
+	}
+	for _, tc := range tests {
+		shape := mkshape(tc.underlying)
+		if !shape.IsShape() {
+			t.Fatalf("%v is not a shape type", shape)
+		}
+		if got := AlgType(shape); got != tc.want {
+			t.Errorf("AlgType(%v) = %v, want %v", shape, got, tc.want)
+		}
+	}
+}
+
+// sharingStructs returns n struct types that all embed the same nested
+// struct type.
+func sharingStructs(n int) []*Type {
+	shared := nestedStruct(8)
+	ts := make([]*Type, n)
+	for i := range ts {
+		ts[i] = mkstruct(shared, NewArray(Types[TUINT8], int64(i)))
+	}
+	return ts
+}
+
+func TestAlgTypes(t *testing.T) {
+	ts := sharingStructs(4)
+	algs := AlgTypes(ts)
+	for i, typ := range ts {
+		if want := AlgType(typ); algs[i] != want {
+			t.Errorf("AlgTypes(...)[%d] = %v, want %v", i, algs[i], want)
+		}
+	}
+}
+
+func BenchmarkAlgTypes(b *testing.B) {
+	ts := sharingStructs(64)
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			AlgTypes(ts)
+		}
+	})
+	b.Run("single", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, t := range ts {
+				AlgType(t)
+			}
+		}
+	})
+}
+
+func TestIsMemComparable(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  *Type
+		want bool
+	}{
+		{"struct{int64; uint32; uint32}", mkstruct(Types[TINT64], Types[TUINT32], Types[TUINT32]), true},
+		{"[4]byte", NewArray(Types[TUINT8], 4), true},
+		{"*int", NewPtr(Types[TINT]), true},
+		{"struct{int64; string}", mkstruct(Types[TINT64], Types[TSTRING]), false},
+		{"float64", Types[TFLOAT64], false},
+		{"[]int", NewSlice(Types[TINT]), false},
+	}
+	for _, tc := range tests {
+		if got := IsMemComparable(tc.typ); got != tc.want {
+			t.Errorf("IsMemComparable(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAlgTypePaddedSingleField(t *testing.T) {
+	// sync/atomic.align64 forces 8-byte alignment on the struct, leaving
+	// padding after a lone uint8 field.
+	atomicPkg := NewPkg("sync/atomic", "atomic")
+	obj := &testObj{sym: atomicPkg.Lookup("align64")}
+	padded := NewNamed(obj)
+	obj.typ = padded
+	padded.SetUnderlying(NewStruct([]*Field{NewField(src.NoXPos, LocalPkg.Lookup("x"), Types[TUINT8])}))
+	CalcSize(padded)
+
+	if padded.Size() != 8 || !IsPaddedField(padded, 0) {
+		t.Fatalf("%v has size %d, want 8 with padding after its field", padded, padded.Size())
+	}
+	if got := AlgType(padded); got != ASPECIAL {
+		t.Errorf("AlgType(%v) = %v, want %v", padded, got, ASPECIAL)
+	}
+	if got := AlgType(NewArray(padded, 4)); got != ASPECIAL {
+		t.Errorf("AlgType([4]%v) = %v, want %v", padded, got, ASPECIAL)
+	}
+
+	if got := AlgType(mkstruct(Types[TUINT8])); got != AMEM {
+		t.Errorf("AlgType(struct{uint8}) = %v, want %v", got, AMEM)
+	}
+}
+
+func TestPaddingBytes(t *testing.T) {
+	i8, i32, i64 := Types[TINT8], Types[TINT32], Types[TINT64]
+	tests := []struct {
+		name string
+		typ  *Type
+		want int64
+	}{
+		{"struct{}", mkstruct(), 0},
+		{"struct{int64; int32; int8; int8}", mkstruct(i64, i32, i8, i8), 2},
+		{"struct{int8; int64; int8}", mkstruct(i8, i64, i8), 14},
+		{"struct{int64; int8}", mkstruct(i64, i8), 7},
+		{"struct{int32; int32}", mkstruct(i32, i32), 0},
+		{"struct{int32; [0]int64}", mkstruct(i32, NewArray(i64, 0)), 12},
+	}
+	for _, tc := range tests {
+		if got := PaddingBytes(tc.typ); got != tc.want {
+			t.Errorf("PaddingBytes(%s) = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestHasPadding(t *testing.T) {
+	i8, i32, i64 := Types[TINT8], Types[TINT32], Types[TINT64]
+	tests := []*Type{
+		mkstruct(),
+		mkstruct(i64, i32, i8, i8),
+		mkstruct(i8, i64, i8),
+		mkstruct(i64, i8),
+		mkstruct(i32, i32),
+		mkstruct(i32, NewArray(i64, 0)),
+	}
+	for _, typ := range tests {
+		if got, want := HasPadding(typ), PaddingBytes(typ) != 0; got != want {
+			t.Errorf("HasPadding(%v) = %v, want %v", typ, got, want)
+		}
+	}
+}
+
+func BenchmarkHasPadding(b *testing.B) {
+	fieldTypes := make([]*Type, 64)
+	for i := range fieldTypes {
+		fieldTypes[i] = Types[TINT32]
+	}
+	t := mkstruct(fieldTypes...)
+	b.Run("HasPadding", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if HasPadding(t) {
+				b.Fatal("unexpected padding")
+			}
+		}
+	})
+	b.Run("IsPaddedField", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range t.NumFields() {
+				if IsPaddedField(t, j) {
+					b.Fatal("unexpected padding")
+				}
+			}
+		}
+	})
+}
+
+func TestComparableComponents(t *testing.T) {
+	// struct{f0 int64; f1 string; f2 int32; f3 int32; f4 float64; _ int32; f6 int8}
+	fields := []*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("f0"), Types[TINT64]),
+		NewField(src.NoXPos, LocalPkg.Lookup("f1"), Types[TSTRING]),
+		NewField(src.NoXPos, LocalPkg.Lookup("f2"), Types[TINT32]),
+		NewField(src.NoXPos, LocalPkg.Lookup("f3"), Types[TINT32]),
+		NewField(src.NoXPos, LocalPkg.Lookup("f4"), Types[TFLOAT64]),
+		NewField(src.NoXPos, BlankSym, Types[TINT32]),
+		NewField(src.NoXPos, LocalPkg.Lookup("f6"), Types[TINT8]),
+	}
+	typ := NewStruct(fields)
+	CalcSize(typ)
+
+	mem, special := ComparableComponents(typ)
+	wantMem := []*Field{fields[0], fields[2], fields[3]}
+	wantSpecial := []*Field{fields[1], fields[4], fields[5], fields[6]}
+	check := func(name string, got, want []*Field) {
+		if len(got) != len(want) {
+			t.Fatalf("%s has %d fields, want %d", name, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s[%d] = %v, want %v", name, i, got[i].Sym, want[i].Sym)
+			}
+		}
+	}
+	check("memFields", mem, wantMem)
+	check("specialFields", special, wantSpecial)
+}
+
+func TestAlgTypeUnionInterface(t *testing.T) {
+	// Type unions only survive in value types when they are equivalent
+	// to any, and the noder folds them to any. So the types1 form of
+	// interface{ any | int } just embeds any, and must compare like any
+	// rather than crashing or reporting it incomparable.
+	union := NewInterface([]*Field{NewField(src.NoXPos, nil, AnyType)})
+	CalcSize(union)
+	if got := AlgType(union); got != ANILINTER {
+		t.Errorf("AlgType(%v) = %v, want %v", union, got, ANILINTER)
+	}
+	if !IsComparable(union) {
+		t.Errorf("IsComparable(%v) = false, want true", union)
+	}
+}
+
+func TestAlgTypeEmbeddedEmptyInterface(t *testing.T) {
+	embed := func(ts ...*Type) *Type {
+		fields := make([]*Field, len(ts))
+		for i, t := range ts {
+			fields[i] = NewField(src.NoXPos, nil, t)
+		}
+		t := NewInterface(fields)
+		CalcSize(t)
+		return t
+	}
+	named := func(name string, underlying *Type) *Type {
+		t := mknamed(name)
+		t.SetUnderlying(underlying)
+		CalcSize(t)
+		return t
+	}
+	// type Empty interface{}
+	empty := named("Empty", NewInterface(nil))
+	// type Nested interface{ Empty }
+	nested := named("Nested", embed(empty))
+	// type Err interface{ error }
+	err := named("Err", embed(ErrorType))
+
+	tests := []struct {
+		name string
+		typ  *Type
+		want AlgKind
+	}{
+		{"any", AnyType, ANILINTER},
+		{"interface{}", Types[TINTER], ANILINTER},
+		{"Empty", empty, ANILINTER},
+		{"interface{ Empty }", embed(empty), ANILINTER},
+		{"interface{ Nested }", embed(nested), ANILINTER},
+		{"interface{ any; Empty }", embed(AnyType, empty), ANILINTER},
+		{"Err", err, AINTER},
+		{"interface{ Err; Empty }", embed(err, empty), AINTER},
+	}
+	for _, tc := range tests {
+		if got := AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+		if got := tc.typ.IsEmptyInterface(); got != (tc.want == ANILINTER) {
+			t.Errorf("%s.IsEmptyInterface() = %v, want %v", tc.name, got, !got)
+		}
+	}
+}
+
+// mknamed returns a new, incomplete named type in the local package.
+func mknamed(name string) *Type {
+	obj := &testObj{sym: LocalPkg.Lookup(name)}
+	t := NewNamed(obj)
+	obj.typ = t
+	return t
+}
+
+func TestCompleteAlg(t *testing.T) {
+	// type List struct{ next *List }
+	list := mknamed("List")
+	listLit := NewStruct([]*Field{NewField(src.NoXPos, LocalPkg.Lookup("next"), NewPtr(list))})
+	if !CompleteAlg(listLit) {
+		t.Errorf("CompleteAlg(%v) = false before List is defined, want true", listLit)
+	}
+	if CompleteAlg(list) {
+		t.Errorf("CompleteAlg(%v) = true before it is defined, want false", list)
+	}
+	list.SetUnderlying(listLit)
+	if !CompleteAlg(list) {
+		t.Errorf("CompleteAlg(%v) = false, want true", list)
+	}
+	if got := AlgType(list); got != AMEM {
+		t.Errorf("AlgType(%v) = %v, want %v", list, got, AMEM)
+	}
+
+	// type Outer struct{ in Inner; s [2]Inner }, with Inner defined later.
+	inner := mknamed("Inner")
+	outer := NewStruct([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("in"), inner),
+		NewField(src.NoXPos, LocalPkg.Lookup("s"), NewArray(inner, 2)),
+	})
+	if CompleteAlg(outer) {
+		t.Errorf("CompleteAlg(%v) = true before Inner is defined, want false", outer)
+	}
+	inner.SetUnderlying(mkstruct(Types[TINT64]))
+	if !CompleteAlg(outer) {
+		t.Errorf("CompleteAlg(%v) = false after Inner is defined, want true", outer)
+	}
+	if got := AlgType(outer); got != AMEM {
+		t.Errorf("AlgType(%v) = %v, want %v", outer, got, AMEM)
+	}
+}
+
+func TestAlgKindLess(t *testing.T) {
+	for a := ANOEQ; a <= ASPECIAL; a++ {
+		if algCost[a] == 0 {
+			t.Errorf("algCost[%v] is not set", a)
+		}
+	}
+	order := []AlgKind{ANOEQ, AMEM, ASTRING, ASPECIAL}
+	for i := range order {
+		for j := range order {
+			if got, want := AlgKindLess(order[i], order[j]), i < j; got != want {
+				t.Errorf("AlgKindLess(%v, %v) = %v, want %v", order[i], order[j], got, want)
+			}
+		}
+	}
+	if !AlgKindLess(AMEM64, AMEM) {
+		t.Errorf("AlgKindLess(AMEM64, AMEM) = false, want true")
+	}
+}
+
+// generatedAlgTests are the types whose need for generated hash
+// functions is checked by TestNeedsGeneratedHash.
+var generatedAlgTests = []struct {
+	name string
+	typ  func() *Type
+	want bool
+}{
+	{"int64", func() *Type { return Types[TINT64] }, false},
+	{"[24]byte", func() *Type { return NewArray(Types[TUINT8], 24) }, false},
+	{"struct{int32; int32}", func() *Type { return mkstruct(Types[TINT32], Types[TINT32]) }, false},
+	{"string", func() *Type { return Types[TSTRING] }, false},
+	{"any", func() *Type { return Types[TINTER] }, false},
+	{"error", func() *Type { return ErrorType }, false},
+	{"float64", func() *Type { return Types[TFLOAT64] }, false},
+	{"complex128", func() *Type { return Types[TCOMPLEX128] }, false},
+	{"[4]float32", func() *Type { return NewArray(Types[TFLOAT32], 4) }, false},
+	{"[]int", func() *Type { return NewSlice(Types[TINT]) }, false},
+	{"map[int]int", func() *Type { return NewMap(Types[TINT], Types[TINT]) }, false},
+	{"struct{int8; int64}", func() *Type { return mkstruct(Types[TINT8], Types[TINT64]) }, true},
+	{"struct{int64; string}", func() *Type { return mkstruct(Types[TINT64], Types[TSTRING]) }, true},
+	{"[3]float32", func() *Type { return NewArray(Types[TFLOAT32], 3) }, true},
+}
+
+func TestNeedsGeneratedHash(t *testing.T) {
+	for _, tc := range generatedAlgTests {
+		if got := NeedsGeneratedHash(tc.typ()); got != tc.want {
+			t.Errorf("NeedsGeneratedHash(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMemCompareRuns(t *testing.T) {
+	i8, i32, i64, str := Types[TINT8], Types[TINT32], Types[TINT64], Types[TSTRING]
+	tests := []struct {
+		name string
+		typ  *Type
+		want []MemRun
+	}{
+		{"struct{int64; int32; int32; string; int64; int64}",
+			mkstruct(i64, i32, i32, str, i64, i64),
+			[]MemRun{{0, 16}, {32, 16}}},
+		{"struct{int8; int64; string}",
+			mkstruct(i8, i64, str),
+			[]MemRun{{0, 1}, {8, 8}}},
+		{"struct{string; float64}", mkstruct(str, Types[TFLOAT64]), nil},
+		{"struct{int64; [0]int64}", mkstruct(i64, NewArray(i64, 0)), []MemRun{{0, 8}}},
+	}
+	for _, tc := range tests {
+		got := MemCompareRuns(tc.typ)
+		if len(got) != len(tc.want) {
+			t.Errorf("MemCompareRuns(%s) = %v, want %v", tc.name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("MemCompareRuns(%s) = %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSuggestReorder(t *testing.T) {
+	i8, i16, i32, i64 := Types[TINT8], Types[TINT16], Types[TINT32], Types[TINT64]
+	orig := mkstruct(i8, i64, i16, i32, i8)
+	if PaddingBytes(orig) == 0 || AlgType(orig) != ASPECIAL {
+		t.Fatalf("%v has no padding", orig)
+	}
+	offsets := make([]int64, orig.NumFields())
+	for i, f := range orig.Fields() {
+		offsets[i] = f.Offset
+	}
+
+	reordered := NewStruct(SuggestReorder(orig))
+	CalcSize(reordered)
+	if n := PaddingBytes(reordered); n != 0 {
+		t.Errorf("reordered struct %v has %d padding bytes, want 0", reordered, n)
+	}
+	if !IsMemComparable(reordered) {
+		t.Errorf("reordered struct %v is not mem-comparable", reordered)
+	}
+	for i, f := range orig.Fields() {
+		if f.Offset != offsets[i] {
+			t.Errorf("SuggestReorder modified offset of field %v", f.Sym)
+		}
+	}
+
+	// Blank fields stay in place.
+	fields := []*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("a"), i8),
+		NewField(src.NoXPos, LocalPkg.Lookup("b"), i64),
+		NewField(src.NoXPos, BlankSym, i8),
+		NewField(src.NoXPos, LocalPkg.Lookup("c"), i8),
+		NewField(src.NoXPos, LocalPkg.Lookup("d"), i32),
+	}
+	blank := NewStruct(fields)
+	var got []string
+	for _, f := range SuggestReorder(blank) {
+		got = append(got, f.Sym.Name)
+	}
+	want := []string{"b", "a", "_", "d", "c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("SuggestReorder order = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestAlgTypeChan(t *testing.T) {
+	for _, dir := range []ChanDir{Cboth, Csend, Crecv} {
+		ch := NewChan(Types[TINT], dir)
+		if got := AlgType(ch); got != AMEM {
+			t.Errorf("AlgType(%v) = %v, want %v", ch, got, AMEM)
+		}
+		if got := AlgType(mkstruct(ch, ch)); got != AMEM {
+			t.Errorf("AlgType(struct{%v; %[1]v}) = %v, want %v", ch, got, AMEM)
+		}
+	}
+}
+
+func TestAlgTypeZeroWidth(t *testing.T) {
+	fn := NewSignature(nil, nil, nil)
+	blankFuncs := NewStruct([]*Field{NewField(src.NoXPos, BlankSym, NewArray(fn, 0))})
+	CalcSize(blankFuncs)
+
+	// The spec makes an array comparable only if its element type is,
+	// whatever its length, so zero-width types built from incomparable
+	// types stay incomparable even though there is nothing to compare.
+	tests := []struct {
+		name string
+		typ  *Type
+		want AlgKind
+	}{
+		{"struct{}", mkstruct(), AMEM},
+		{"[0]int", NewArray(Types[TINT], 0), AMEM},
+		{"[0]string", NewArray(Types[TSTRING], 0), AMEM},
+		{"[0]func()", NewArray(fn, 0), ANOEQ},
+		{"struct{_ [0]func()}", blankFuncs, ANOEQ},
+	}
+	for _, tc := range tests {
+		if got := AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+		if tc.typ.Size() != 0 {
+			t.Errorf("%s has size %d, want 0", tc.name, tc.typ.Size())
+		}
+	}
+}
+
+func TestEqualAlg(t *testing.T) {
+	named := func(name string, underlying *Type) *Type {
+		t := mknamed(name)
+		t.SetUnderlying(underlying)
+		CalcSize(t)
+		return t
+	}
+	// type A struct{ f0 int8; f1 string }
+	// type B struct{ f0 int8; f1 string }
+	a := named("A", mkstruct(Types[TINT8], Types[TSTRING]))
+	b := named("B", mkstruct(Types[TINT8], Types[TSTRING]))
+	// type C struct{ f0 int8; _ [7]byte; f1 string }
+	explicitPad := NewStruct([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("f0"), Types[TINT8]),
+		NewField(src.NoXPos, BlankSym, NewArray(Types[TUINT8], 7)),
+		NewField(src.NoXPos, LocalPkg.Lookup("f1"), Types[TSTRING]),
+	})
+	c := named("C", explicitPad)
+	// type D struct{ f0 int32; f1 string }
+	d := named("D", mkstruct(Types[TINT32], Types[TSTRING]))
+
+	tests := []struct {
+		x, y *Type
+		want bool
+	}{
+		{a, a, true},
+		{a, b, true},
+		{NewArray(a, 3), NewArray(b, 3), true},
+		{NewArray(a, 3), NewArray(b, 4), false},
+		{a, c, false},
+		{a, d, false},
+		{Types[TINT64], Types[TUINT64], true},
+		{Types[TINT32], Types[TINT64], false},
+		{Types[TFLOAT64], Types[TINT64], false},
+		{mkstruct(Types[TINT32], Types[TINT32]), Types[TINT64], true},
+	}
+	for _, tc := range tests {
+		if got := EqualAlg(tc.x, tc.y); got != tc.want {
+			t.Errorf("EqualAlg(%v, %v) = %v, want %v", tc.x, tc.y, got, tc.want)
+		}
+		if got := EqualAlg(tc.y, tc.x); got != tc.want {
+			t.Errorf("EqualAlg(%v, %v) = %v, want %v", tc.y, tc.x, got, tc.want)
+		}
+	}
+	if a.Size() != c.Size() {
+		t.Fatalf("%v and %v have sizes %d and %d, want equal", a, c, a.Size(), c.Size())
+	}
+}
+
+func TestInvalidateAlg(t *testing.T) {
+	s := mkstruct(Types[TINT], Types[TINT])
+	if got := AlgType(s); got != AMEM {
+		t.Fatalf("AlgType(%v) = %v, want %v", s, got, AMEM)
+	}
+
+	InvalidateAlg(s)
+	s.setFields([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("f0"), Types[TINT]),
+		NewField(src.NoXPos, LocalPkg.Lookup("f1"), Types[TSTRING]),
+	})
+	if got := AlgType(s); got != ASPECIAL {
+		t.Errorf("AlgType(%v) after adding string field = %v, want %v", s, got, ASPECIAL)
+	}
+	if got, want := s.Size(), int64(24); got != want {
+		t.Errorf("Size(%v) = %d, want %d", s, got, want)
+	}
+
+	InvalidateAlg(s)
+	s.setFields([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("f0"), Types[TINT]),
+		NewField(src.NoXPos, LocalPkg.Lookup("f1"), NewSignature(nil, nil, nil)),
+	})
+	if got := AlgType(s); got != ANOEQ {
+		t.Errorf("AlgType(%v) after adding func field = %v, want %v", s, got, ANOEQ)
+	}
+}
+
+func TestResetAlgRecords(t *testing.T) {
+	s := mkstruct(Types[TINT], Types[TINT])
+	if got := AlgType(s); got != AMEM {
+		t.Fatalf("AlgType(%v) = %v, want %v", s, got, AMEM)
+	}
+	algRecords[s] = algRecord{Name: "s", Kind: s.alg.String()}
+
+	ResetAlgRecords()
+	if len(algRecords) != 0 {
+		t.Errorf("ResetAlgRecords left %d alg records", len(algRecords))
+	}
+	if !s.widthCalculated() || s.alg != AMEM {
+		t.Errorf("ResetAlgRecords changed the layout cached on %v", s)
+	}
+}
+
+func TestIsSelfComparable(t *testing.T) {
+	anyType := Types[TINTER]
+	blankAny := NewStruct([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("x"), Types[TINT]),
+		NewField(src.NoXPos, BlankSym, anyType),
+	})
+	CalcSize(blankAny)
+
+	tests := []struct {
+		name string
+		typ  *Type
+		want bool
+	}{
+		{"struct{x int; y string}", mkstruct(Types[TINT], Types[TSTRING]), true},
+		{"struct{x int; y any}", mkstruct(Types[TINT], anyType), false},
+		{"struct{x int; y error}", mkstruct(Types[TINT], ErrorType), false},
+		{"struct{x int; _ any}", blankAny, true},
+		{"struct{s struct{x int; y any}}", mkstruct(mkstruct(Types[TINT], anyType)), false},
+		{"[2]struct{x int; y any}", NewArray(mkstruct(Types[TINT], anyType), 2), false},
+		{"[2]string", NewArray(Types[TSTRING], 2), true},
+		{"[0]any", NewArray(anyType, 0), true},
+		{"any", anyType, false},
+		{"float64", Types[TFLOAT64], true},
+		{"*any", NewPtr(anyType), true},
+		{"[]int", NewSlice(Types[TINT]), false},
+	}
+	for _, tc := range tests {
+		if got := IsSelfComparable(tc.typ); got != tc.want {
+			t.Errorf("IsSelfComparable(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// algBenchTypes returns a representative set of types for measuring
+// AlgType.
+func algBenchTypes() []struct {
+	name string
+	typ  *Type
+} {
+	flat := make([]*Type, 16)
+	for i := range flat {
+		flat[i] = Types[TINT64]
+	}
+	return []struct {
+		name string
+		typ  *Type
+	}{
+		{"Flat", mkstruct(flat...)},
+		{"Nested", nestedStruct(16)},
+		{"LargeArray", NewArray(mkstruct(Types[TINT64], Types[TSTRING]), 1<<20)},
+		{"Interface", mkstruct(Types[TINT], Types[TINTER], ErrorType)},
+	}
+}
+
+func BenchmarkAlgType(b *testing.B) {
+	for _, bt := range algBenchTypes() {
+		b.Run(bt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				AlgType(bt.typ)
+			}
+		})
+	}
+}
+
+// BenchmarkAlgTypeDefinedStruct measures AlgType for new defined types
+// sharing one underlying struct type. SetUnderlying copies the size and
+// AlgKind already computed for the underlying type, so defined types with
+// identical layouts share that computation and AlgType does no work.
+func BenchmarkAlgTypeDefinedStruct(b *testing.B) {
+	fields := make([]*Type, 64)
+	for i := range fields {
+		fields[i] = Types[TINT64]
+	}
+	fields[len(fields)-1] = Types[TSTRING]
+	underlying := mkstruct(fields...)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		typ := mknamed("T")
+		typ.SetUnderlying(underlying)
+		b.StartTimer()
+		if AlgType(typ) != ASPECIAL {
+			b.Fatal("unexpected alg")
+		}
+	}
+}
+
+func TestAlgTypeDefinedStructShared(t *testing.T) {
+	underlying := mkstruct(Types[TINT64], Types[TSTRING])
+	for _, name := range []string{"A", "B"} {
+		typ := mknamed(name)
+		typ.SetUnderlying(underlying)
+		if !typ.widthCalculated() || typ.alg != underlying.alg {
+			t.Errorf("%s: SetUnderlying did not copy the alg of %v", name, underlying)
+		}
+	}
+}
+
+func TestAlgTypeNoAlloc(t *testing.T) {
+	for _, bt := range algBenchTypes() {
+		AlgType(bt.typ)
+		if n := testing.AllocsPerRun(100, func() { AlgType(bt.typ) }); n != 0 {
+			t.Errorf("AlgType(%s) allocates %v times, want 0", bt.name, n)
+		}
+	}
+}
+
+func TestAlgTypeIncomparableCached(t *testing.T) {
+	m := NewMap(Types[TSTRING], Types[TINT])
+	s := mkstruct(Types[TINT], mkstruct(Types[TSTRING], m))
+	if got := AlgType(s); got != ANOEQ {
+		t.Fatalf("AlgType(%v) = %v, want %v", s, got, ANOEQ)
+	}
+
+	// Swap in a comparable field behind AlgType's back. If any later call
+	// walked s again, it would now find s comparable.
+	f := s.Field(1)
+	orig := f.Type
+	f.Type = Types[TSTRING]
+	defer func() { f.Type = orig }()
+	for i := 0; i < 1000; i++ {
+		if got := AlgType(s); got != ANOEQ {
+			t.Fatalf("AlgType(%v) = %v on call %d, want cached %v", s, got, i, ANOEQ)
+		}
+		if IsComparable(s) {
+			t.Fatalf("IsComparable(%v) = true on call %d, want cached false", s, i)
+		}
+	}
+}
+
+func TestAlgKindClassification(t *testing.T) {
+	for a := AUNK; a <= ASPECIAL; a++ {
+		var wantMem, wantSpecial bool
+		switch a {
+		case AMEM, AMEM0, AMEM8, AMEM16, AMEM32, AMEM64, AMEM128, AMEM256:
+			wantMem = true
+		case ASPECIAL:
+			wantSpecial = true
+		case AUNK, ANOEQ, ANOALG, ASTRING, AINTER, ANILINTER,
+			AFLOAT32, AFLOAT64, ACPLX64, ACPLX128,
+			AFLOAT32x4, AFLOAT64x2, AFLOAT64x4:
+		default:
+			t.Errorf("AlgKind %v is not classified by this test", a)
+			continue
+		}
+		if got := a.IsMem(); got != wantMem {
+			t.Errorf("%v.IsMem() = %v, want %v", a, got, wantMem)
+		}
+		if got := a.IsSpecial(); got != wantSpecial {
+			t.Errorf("%v.IsSpecial() = %v, want %v", a, got, wantSpecial)
+		}
+		if got := a.NeedsGenerated(); got != wantSpecial {
+			t.Errorf("%v.NeedsGenerated() = %v, want %v", a, got, wantSpecial)
+		}
+	}
+}
+
+func TestSliceCompareHint(t *testing.T) {
+	for _, elem := range []*Type{Types[TINT], Types[TSTRING], NewSlice(Types[TINT])} {
+		s := NewSlice(elem)
+		hint := SliceCompareHint(s)
+		if !strings.Contains(hint, "can only be compared to nil") || !strings.Contains(hint, "regardless of its element type") {
+			t.Errorf("SliceCompareHint(%v) = %q, want explanation that slices are never comparable", s, hint)
+		}
+	}
+	if hint := SliceCompareHint(NewSlice(Types[TUINT8])); !strings.Contains(hint, "bytes.Equal") {
+		t.Errorf("SliceCompareHint([]byte) = %q, want mention of bytes.Equal", hint)
+	}
+	if hint := SliceCompareHint(NewSlice(Types[TSTRING])); !strings.Contains(hint, "slices.Equal") {
+		t.Errorf("SliceCompareHint([]string) = %q, want mention of slices.Equal", hint)
+	}
+	for _, typ := range []*Type{Types[TINT], NewArray(Types[TINT], 2), NewMap(Types[TINT], Types[TINT])} {
+		if hint := SliceCompareHint(typ); hint != "" {
+			t.Errorf("SliceCompareHint(%v) = %q, want \"\"", typ, hint)
+		}
+	}
+}
+
+func TestAlgOverride(t *testing.T) {
+	s := mkstruct(Types[TINT64], Types[TINT64])
+	arr := NewArray(Types[TINT32], 4)
+	str := mkstruct(Types[TINT64], Types[TSTRING])
+
+	AlgOverride[AMEM] = ASPECIAL
+	defer delete(AlgOverride, AMEM)
+
+	// Without -d=alghash, overrides are ignored.
+	if got := AlgType(s); got != AMEM {
+		t.Errorf("AlgType(%v) = %v without -d=alghash, want %v", s, got, AMEM)
+	}
+
+	base.Debug.AlgHash = 1
+	defer func() { base.Debug.AlgHash = 0 }()
+	tests := []struct {
+		typ  *Type
+		want AlgKind
+	}{
+		{s, ASPECIAL},
+		{str, ASPECIAL},
+		{NewArray(s, 2), AMEM},
+		// Overrides only apply to struct types.
+		{arr, AMEM},
+		{Types[TINT64], AMEM},
+		{NewPtr(Types[TINT]), AMEM},
+		{Types[TSTRING], ASTRING},
+	}
+	for _, tc := range tests {
+		if got := AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%v) = %v with override, want %v", tc.typ, got, tc.want)
+		}
+	}
+}
+
+func TestAlgTypeUnsafePointer(t *testing.T) {
+	up := Types[TUNSAFEPTR]
+	tests := []struct {
+		name       string
+		typ        *Type
+		want       AlgKind
+		containsUP bool
+	}{
+		{"unsafe.Pointer", up, AMEM, true},
+		{"struct{p unsafe.Pointer; n uintptr}", mkstruct(up, Types[TUINTPTR]), AMEM, true},
+		{"struct{p unsafe.Pointer; b bool}", mkstruct(up, Types[TBOOL]), ASPECIAL, true},
+		{"struct{b bool; p unsafe.Pointer}", mkstruct(Types[TBOOL], up), ASPECIAL, true},
+		{"[2]struct{p unsafe.Pointer}", NewArray(mkstruct(up), 2), AMEM, true},
+		{"[0]unsafe.Pointer", NewArray(up, 0), AMEM, false},
+		{"struct{s struct{p unsafe.Pointer}; n int}", mkstruct(mkstruct(up), Types[TINT]), AMEM, true},
+		{"*unsafe.Pointer", NewPtr(up), AMEM, false},
+		{"struct{p *int; n int}", mkstruct(NewPtr(Types[TINT]), Types[TINT]), AMEM, false},
+	}
+	for _, tc := range tests {
+		if got := AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+		if got := ContainsUnsafePtr(tc.typ); got != tc.containsUP {
+			t.Errorf("ContainsUnsafePtr(%s) = %v, want %v", tc.name, got, tc.containsUP)
+		}
+	}
+}
+
+func TestWalkAlg(t *testing.T) {
+	// type inner struct{ s string; _ int32; x int32 }
+	inner := NewStruct([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("s"), Types[TSTRING]),
+		NewField(src.NoXPos, BlankSym, Types[TINT32]),
+		NewField(src.NoXPos, LocalPkg.Lookup("x"), Types[TINT32]),
+	})
+	// type outer struct{ a int; b [2]inner; z [0]string; c float64 }
+	outer := NewStruct([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("a"), Types[TINT]),
+		NewField(src.NoXPos, LocalPkg.Lookup("b"), NewArray(inner, 2)),
+		NewField(src.NoXPos, LocalPkg.Lookup("z"), NewArray(Types[TSTRING], 0)),
+		NewField(src.NoXPos, LocalPkg.Lookup("c"), Types[TFLOAT64]),
+	})
+	CalcSize(outer)
+
+	var got []string
+	WalkAlg(outer, func(path []*Field, elemIndex int, a AlgKind) {
+		var names []string
+		for _, f := range path {
+			names = append(names, f.Sym.Name)
+		}
+		got = append(got, fmt.Sprintf("%s[%d] %v", strings.Join(names, "."), elemIndex, a))
+	})
+	want := []string{
+		"a[-1] MEM",
+		"b.s[0] STRING",
+		"b._[0] MEM",
+		"b.x[0] MEM",
+		"b.s[1] STRING",
+		"b._[1] MEM",
+		"b.x[1] MEM",
+		"c[-1] FLOAT64",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkAlg(%v) visited\n\t%s\nwant\n\t%s", outer, strings.Join(got, "\n\t"), strings.Join(want, "\n\t"))
+	}
+
+	got = nil
+	WalkAlg(Types[TSTRING], func(path []*Field, elemIndex int, a AlgKind) {
+		got = append(got, fmt.Sprintf("%d %d %v", len(path), elemIndex, a))
+	})
+	if want := []string{"0 -1 STRING"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkAlg(string) visited %v, want %v", got, want)
+	}
+	WalkAlg(NewArray(Types[TSTRING], 0), func([]*Field, int, AlgKind) {
+		t.Errorf("WalkAlg([0]string) visited a unit")
+	})
+}
+
+// A typeGen builds random but valid type trees from fuzzer input.
+type typeGen struct {
+	data []byte
+}
+
+func (g *typeGen) next() int {
+	if len(g.data) == 0 {
+		return 0
+	}
+	b := g.data[0]
+	g.data = g.data[1:]
+	return int(b)
+}
+
+var fuzzBasicKinds = [...]Kind{
+	TINT8, TINT16, TINT32, TINT64, TBOOL, TFLOAT32, TFLOAT64,
+	TCOMPLEX64, TCOMPLEX128, TSTRING, TUNSAFEPTR, TINTER,
+}
+
+const (
+	genPtr = len(fuzzBasicKinds) + iota
+	genSlice
+	genMap
+	genFunc
+	genChan
+	genArray
+	genStruct
+	genOps
+)
+
+// gen returns a random type of at most the given depth, along with
+// whether the spec says it is comparable.
+func (g *typeGen) gen(depth int) (*Type, bool) {
+	op := g.next() % genOps
+	if depth == 0 {
+		op %= len(fuzzBasicKinds)
+	}
+	switch op {
+	case genPtr:
+		elem, _ := g.gen(depth - 1)
+		return NewPtr(elem), true
+	case genSlice:
+		elem, _ := g.gen(depth - 1)
+		return NewSlice(elem), false
+	case genMap:
+		elem, _ := g.gen(depth - 1)
+		return NewMap(Types[TINT], elem), false
+	case genFunc:
+		return NewSignature(nil, nil, nil), false
+	case genChan:
+		elem, _ := g.gen(depth - 1)
+		return NewChan(elem, Cboth), true
+	case genArray:
+		n := int64(g.next() % 4)
+		elem, ok := g.gen(depth - 1)
+		return NewArray(elem, n), ok
+	case genStruct:
+		n := g.next() % 4
+		fields := make([]*Field, n)
+		comparable := true
+		for i := range fields {
+			sym := LocalPkg.LookupNum("f", i)
+			if g.next()&1 != 0 {
+				sym = BlankSym
+			}
+			ftyp, ok := g.gen(depth - 1)
+			comparable = comparable && ok
+			fields[i] = NewField(src.NoXPos, sym, ftyp)
+		}
+		return NewStruct(fields), comparable
+	}
+	return Types[fuzzBasicKinds[op]], true
+}
+
+func FuzzAlgType(f *testing.F) {
+	f.Add([]byte{byte(genArray), 2, byte(genArray), 3, 9})           // [2][3]string
+	f.Add([]byte{byte(genStruct), 2, 0, 3, 0, 11})                   // struct{f0 int64; f1 any}
+	f.Add([]byte{byte(genStruct), 2, 0, 0, 0, 3})                    // struct{f0 int8; f1 int64}
+	f.Add([]byte{byte(genStruct), 2, 1, 0, 0, byte(genArray), 1, 6}) // struct{_ int8; f1 [1]float64}
+	f.Add([]byte{byte(genArray), 0, byte(genSlice), 2})              // [0][]int32
+	f.Add([]byte{byte(genStruct), 1, 0, byte(genStruct), 1, 0, 11})  // struct{f0 struct{f0 any}}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		g := &typeGen{data: data}
+		typ, comparable := g.gen(4)
+		a := AlgType(typ)
+		if a == AUNK || a > ASPECIAL {
+			t.Fatalf("AlgType(%v) = %v", typ, a)
+		}
+		if got := IsComparable(typ); got != comparable {
+			t.Fatalf("IsComparable(%v) = %v (alg %v), want %v", typ, got, a, comparable)
+		}
+		if typ.IsStruct() && a == AMEM && HasPadding(typ) {
+			t.Fatalf("AlgType(%v) = %v, but it has padding", typ, a)
+		}
+		if comparable && NeedsPaddingZeroed(typ) && a != ASPECIAL {
+			t.Fatalf("AlgType(%v) = %v, but it contains padding", typ, a)
+		}
+	})
+}
+
+func TestNeedsPaddingZeroed(t *testing.T) {
+	padded := mkstruct(Types[TINT8], Types[TINT64])
+	tests := []struct {
+		name string
+		typ  *Type
+		want bool
+	}{
+		{"struct{int8; int64}", padded, true},
+		{"struct{int64; int8}", mkstruct(Types[TINT64], Types[TINT8]), true},
+		{"struct{int64; struct{int8; int64}}", mkstruct(Types[TINT64], padded), true},
+		{"[2]struct{int8; int64}", NewArray(padded, 2), true},
+		{"[0]struct{int8; int64}", NewArray(padded, 0), false},
+		{"struct{int32; int32}", mkstruct(Types[TINT32], Types[TINT32]), false},
+		{"struct{int64; string}", mkstruct(Types[TINT64], Types[TSTRING]), false},
+		{"int64", Types[TINT64], false},
+	}
+	for _, tc := range tests {
+		if got := NeedsPaddingZeroed(tc.typ); got != tc.want {
+			t.Errorf("NeedsPaddingZeroed(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+		if tc.want {
+			if a := AlgType(tc.typ); a != ASPECIAL {
+				t.Errorf("AlgType(%s) = %v, want %v", tc.name, a, ASPECIAL)
+			}
+		}
+	}
+}
+
+func TestUniformArrayAlg(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  *Type
+		want AlgKind
+		ok   bool
+	}{
+		{"[4]string", NewArray(Types[TSTRING], 4), ASTRING, true},
+		{"[4]struct{x string}", NewArray(mkstruct(Types[TSTRING]), 4), ASTRING, true},
+		{"[4]struct{x uint64}", NewArray(mkstruct(Types[TUINT64]), 4), AMEM, true},
+		{"[3]float64", NewArray(Types[TFLOAT64], 3), AFLOAT64, true},
+		{"[2]struct{x complex128}", NewArray(mkstruct(Types[TCOMPLEX128]), 2), ACPLX128, true},
+		{"[4]any", NewArray(Types[TINTER], 4), ANILINTER, true},
+		{"[4]struct{x uint64; y string}", NewArray(mkstruct(Types[TUINT64], Types[TSTRING]), 4), ASPECIAL, false},
+		{"[4]struct{x int8; y int64}", NewArray(mkstruct(Types[TINT8], Types[TINT64]), 4), ASPECIAL, false},
+		{"[4][]int", NewArray(NewSlice(Types[TINT]), 4), ANOEQ, false},
+	}
+	for _, tc := range tests {
+		got, ok := UniformArrayAlg(tc.typ)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("UniformArrayAlg(%s) = %v, %v, want %v, %v", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestAlgDescriptor(t *testing.T) {
+	for _, typ := range []*Type{
+		Types[TINT8],
+		Types[TSTRING],
+		Types[TINTER],
+		Types[TCOMPLEX128],
+		NewPtr(Types[TINT]),
+		NewSlice(Types[TINT]),
+		NewArray(Types[TINT16], 3),
+		mkstruct(),
+		mkstruct(Types[TINT64], Types[TINT64]),
+		mkstruct(Types[TINT8], Types[TINT64]),
+		mkstruct(Types[TINT64], Types[TINT8]),
+		mkstruct(Types[TSTRING], Types[TFLOAT32]),
+		NewArray(mkstruct(Types[TINT8], Types[TINT64]), 2),
+	} {
+		got := AlgDescriptor(typ)
+		want := AlgDesc{
+			Kind:       AlgType(typ),
+			Size:       typ.Size(),
+			Align:      typ.Alignment(),
+			HasPadding: typ.IsStruct() && HasPadding(typ),
+		}
+		if got != want {
+			t.Errorf("AlgDescriptor(%v) = %+v, want %+v", typ, got, want)
+		}
+	}
+}
+
+func TestAlgTypeRecursive(t *testing.T) {
+	// recursive returns type name struct{ ...fields }, where fields may
+	// refer to the type being defined.
+	recursive := func(name string, fields func(self *Type) []*Type) *Type {
+		self := mknamed(name)
+		ftypes := fields(self)
+		fs := make([]*Field, len(ftypes))
+		for i, ft := range ftypes {
+			fs[i] = NewField(src.NoXPos, LocalPkg.LookupNum("f", i), ft)
+		}
+		self.SetUnderlying(NewStruct(fs))
+		return self
+	}
+
+	tests := []struct {
+		name string
+		typ  *Type
+		want AlgKind
+	}{
+		{"type List struct{ next *List; v int }", recursive("List", func(self *Type) []*Type {
+			return []*Type{NewPtr(self), Types[TINT]}
+		}), AMEM},
+		{"type Tree struct{ kids [2]*Tree }", recursive("Tree", func(self *Type) []*Type {
+			return []*Type{NewArray(NewPtr(self), 2)}
+		}), AMEM},
+		{"type Chain struct{ c chan Chain }", recursive("Chain", func(self *Type) []*Type {
+			return []*Type{NewChan(self, Cboth)}
+		}), AMEM},
+		{"type Any struct{ self *Any; v any }", recursive("Any", func(self *Type) []*Type {
+			return []*Type{NewPtr(self), Types[TINTER]}
+		}), ASPECIAL},
+		{"type Kids struct{ kids []*Kids; v int }", recursive("Kids", func(self *Type) []*Type {
+			return []*Type{NewSlice(NewPtr(self)), Types[TINT]}
+		}), ANOEQ},
+		{"type Index struct{ m map[*Index]int }", recursive("Index", func(self *Type) []*Type {
+			return []*Type{NewMap(NewPtr(self), Types[TINT])}
+		}), ANOEQ},
+		{"type Fn struct{ f func(Fn) Fn }", recursive("Fn", func(self *Type) []*Type {
+			return []*Type{NewSignature(nil,
+				[]*Field{NewField(src.NoXPos, nil, self)},
+				[]*Field{NewField(src.NoXPos, nil, self)})}
+		}), ANOEQ},
+	}
+	for _, tc := range tests {
+		if got := AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAlgTypeNoalg(t *testing.T) {
+	// A struct whose layout is comparable, but which is marked Noalg, as
+	// the compiler does for map buckets.
+	s := NewStruct([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("k"), Types[TINT64]),
+		NewField(src.NoXPos, LocalPkg.Lookup("v"), Types[TSTRING]),
+	})
+	s.SetNoalg(true)
+	CalcSize(s)
+
+	for _, typ := range []*Type{s, NewPtr(s), NewArray(s, 2), mkstruct(Types[TINT], s)} {
+		if got := AlgType(typ); got != ANOALG {
+			t.Errorf("AlgType(%v) = %v, want %v", typ, got, ANOALG)
+		}
+		if IsComparable(typ) {
+			t.Errorf("IsComparable(%v) = true, want false", typ)
+		}
+		if !TypeHasNoAlg(typ) {
+			t.Errorf("TypeHasNoAlg(%v) = false, want true", typ)
+		}
+		if NeedsGeneratedHash(typ) || NeedsGeneratedEq(typ) {
+			t.Errorf("%v needs generated hash or equality functions, want neither", typ)
+		}
+	}
+}
+
+func TestMapKeyViolation(t *testing.T) {
+	m := NewMap(Types[TSTRING], Types[TINT])
+	slice := NewSlice(Types[TINT])
+
+	inner := mkstruct(Types[TINT], m)
+	middle := mkstruct(Types[TSTRING], inner)
+	outer := mkstruct(Types[TINT64], middle)
+	sliceFirst := mkstruct(slice, inner)
+	arrays := mkstruct(NewArray(mkstruct(Types[TINT], NewArray(m, 2)), 3))
+
+	tests := []struct {
+		name string
+		typ  *Type
+		want *Field
+	}{
+		{"struct{int64; struct{string; struct{int; map}}}", outer, inner.Field(1)},
+		{"struct{[]int; struct{int; map}}", sliceFirst, inner.Field(1)},
+		{"struct{[3]struct{int; [2]map}}", arrays, arrays.Field(0).Type.Elem().Field(1)},
+		{"struct{int; []int}", mkstruct(Types[TINT], slice), nil},
+		{"struct{int; string}", mkstruct(Types[TINT], Types[TSTRING]), nil},
+	}
+	for _, tc := range tests {
+		if got := MapKeyViolation(tc.typ); got != tc.want {
+			t.Errorf("MapKeyViolation(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestInterfaceCompareKind(t *testing.T) {
+	method := func(name string) *Field {
+		return NewField(src.NoXPos, LocalPkg.Lookup(name), NewSignature(FakeRecv(), nil, nil))
+	}
+	twoMethods := NewInterface([]*Field{method("M1"), method("M2")})
+	CalcSize(twoMethods)
+
+	tests := []struct {
+		name   string
+		typ    *Type
+		want   AlgKind
+		helper string
+	}{
+		{"any", Types[TINTER], ANILINTER, "efaceeq"},
+		{"error", ErrorType, AINTER, "ifaceeq"},
+		{"interface{ M1(); M2() }", twoMethods, AINTER, "ifaceeq"},
+	}
+	for _, tc := range tests {
+		a, helper := InterfaceCompareKind(tc.typ)
+		if a != tc.want || helper != tc.helper {
+			t.Errorf("InterfaceCompareKind(%s) = %v, %q, want %v, %q", tc.name, a, helper, tc.want, tc.helper)
+		}
+	}
+}
+
+func TestIsTailPadded(t *testing.T) {
+	i8, i16, i64 := Types[TINT8], Types[TINT16], Types[TINT64]
+	tests := []struct {
+		name         string
+		typ          *Type
+		tail, padded bool
+	}{
+		{"struct{}", mkstruct(), false, false},
+		{"struct{int64; int64}", mkstruct(i64, i64), false, false},
+		{"struct{int64; int8}", mkstruct(i64, i8), true, true},
+		{"struct{int8; int16}", mkstruct(i8, i16), false, true},
+		{"struct{int8; int64; int8}", mkstruct(i8, i64, i8), true, true},
+	}
+	for _, tc := range tests {
+		if got := IsTailPadded(tc.typ); got != tc.tail {
+			t.Errorf("IsTailPadded(%s) = %v, want %v", tc.name, got, tc.tail)
+		}
+		if got := HasPadding(tc.typ); got != tc.padded {
+			t.Errorf("HasPadding(%s) = %v, want %v", tc.name, got, tc.padded)
+		}
+	}
+}
+
+func TestAlgTypeNamed(t *testing.T) {
+	named := func(name string, underlying *Type) *Type {
+		t := mknamed(name)
+		t.SetUnderlying(underlying)
+		return t
+	}
+	twoMethods := NewInterface([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("M1"), NewSignature(FakeRecv(), nil, nil)),
+		NewField(src.NoXPos, LocalPkg.Lookup("M2"), NewSignature(FakeRecv(), nil, nil)),
+	})
+	myStruct := named("MyStruct", mkstruct(Types[TINT64], Types[TSTRING]))
+
+	for _, underlying := range []*Type{
+		Types[TINT],
+		Types[TSTRING],
+		Types[TFLOAT64],
+		mkstruct(Types[TINT64], Types[TINT64]),
+		mkstruct(Types[TINT8], Types[TINT64]),
+		mkstruct(Types[TINT64], Types[TSTRING]),
+		mkstruct(Types[TINT], NewSlice(Types[TINT])),
+		NewArray(Types[TINT32], 4),
+		NewArray(Types[TSTRING], 4),
+		NewArray(Types[TFLOAT32], 4),
+		Types[TINTER],
+		twoMethods,
+		NewSlice(Types[TINT]),
+		myStruct,
+		NewArray(myStruct, 2),
+	} {
+		typ := named("T", underlying)
+		if got, want := AlgType(typ), AlgType(underlying); got != want {
+			t.Errorf("AlgType(%v) = %v, want %v, the AlgType of its underlying type %v", typ, got, want, underlying)
+		}
+	}
+}
+
+func TestCompareCost(t *testing.T) {
+	small := mkstruct(Types[TINT32], Types[TINT32])
+	medium := mkstruct(Types[TINT64], Types[TSTRING])
+	fields := make([]*Type, 16)
+	for i := range fields {
+		fields[i] = mkstruct(Types[TSTRING], Types[TFLOAT64])
+	}
+	large := mkstruct(fields...)
+	huge := NewArray(large, 1<<40)
+	slice := NewSlice(Types[TINT])
+
+	// Each type is expected to cost more to compare than the one before.
+	ordered := []*Type{small, medium, NewArray(medium, 4), large, huge, slice}
+	for i := 1; i < len(ordered); i++ {
+		prev, cur := ordered[i-1], ordered[i]
+		if CompareCost(prev) >= CompareCost(cur) {
+			t.Errorf("CompareCost(%v) = %d, want less than CompareCost(%v) = %d",
+				prev, CompareCost(prev), cur, CompareCost(cur))
+		}
+	}
+
+	for _, tc := range []struct {
+		typ  *Type
+		want int
+	}{
+		{mkstruct(), 0},
+		{Types[TINT8], 1},
+		{small, 1},
+		{NewArray(Types[TINT64], 3), 3},
+		{Types[TFLOAT64], 1},
+		{Types[TCOMPLEX128], 2},
+		{Types[TSTRING], 3},
+		{medium, 4},
+		{large, 16 * 4},
+		{NewMap(Types[TINT], Types[TINT]), noEqCost},
+	} {
+		if got := CompareCost(tc.typ); got != tc.want {
+			t.Errorf("CompareCost(%v) = %d, want %d", tc.typ, got, tc.want)
+		}
+	}
+}
+
+func TestFitsInRegisters(t *testing.T) {
+	// Register assignment depends only on PtrSize and RegSize, which the
+	// tests set as on 64-bit architectures such as amd64 and arm64.
+	u64 := Types[TUINT64]
+	tests := []struct {
+		name string
+		typ  *Type
+		want bool
+	}{
+		{"struct{}", mkstruct(), true},
+		{"uint64", u64, true},
+		{"struct{a, b uint64}", mkstruct(u64, u64), true},
+		{"struct{p *int; n int}", mkstruct(NewPtr(Types[TINT]), Types[TINT]), true},
+		{"struct{a, b, c uint64}", mkstruct(u64, u64, u64), false},
+		{"struct{a, b, c, d uint8}", mkstruct(Types[TUINT8], Types[TUINT8], Types[TUINT8], Types[TUINT8]), false},
+		{"[2]uint64", NewArray(u64, 2), false},
+		{"struct{a uint32; b uint64}", mkstruct(Types[TUINT32], u64), false},
+		{"struct{a, b float64}", mkstruct(Types[TFLOAT64], Types[TFLOAT64]), false},
+		{"string", Types[TSTRING], false},
+	}
+	for _, tc := range tests {
+		if got := FitsInRegisters(tc.typ); got != tc.want {
+			t.Errorf("FitsInRegisters(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+		if tc.want && AlgType(tc.typ) != AMEM {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, AlgType(tc.typ), AMEM)
+		}
+	}
+}
+
+func TestIsComparableReflectParity(t *testing.T) {
+	fn := NewSignature(nil, nil, nil)
+	blankSlice := NewStruct([]*Field{NewField(src.NoXPos, BlankSym, NewSlice(Types[TINT]))})
+	CalcSize(blankSlice)
+
+	tests := []struct {
+		typ *Type
+		rt  reflect.Type
+	}{
+		{Types[TINT], reflect.TypeOf(int(0))},
+		{Types[TSTRING], reflect.TypeOf("")},
+		{Types[TFLOAT64], reflect.TypeOf(float64(0))},
+		{Types[TINTER], reflect.TypeOf((*any)(nil)).Elem()},
+		{ErrorType, reflect.TypeOf((*error)(nil)).Elem()},
+		{NewPtr(Types[TINT]), reflect.TypeOf((*int)(nil))},
+		{NewChan(Types[TINT], Cboth), reflect.TypeOf((chan int)(nil))},
+		{NewSlice(Types[TINT]), reflect.TypeOf([]int(nil))},
+		{NewMap(Types[TINT], Types[TINT]), reflect.TypeOf(map[int]int(nil))},
+		{fn, reflect.TypeOf((func())(nil))},
+		{mkstruct(), reflect.TypeOf(struct{}{})},
+		{mkstruct(Types[TINT], Types[TINTER]), reflect.TypeOf(struct {
+			a int
+			b any
+		}{})},
+		{mkstruct(Types[TINT], fn), reflect.TypeOf(struct {
+			a int
+			b func()
+		}{})},
+		{blankSlice, reflect.TypeOf(struct{ _ []int }{})},
+		// The spec makes an array comparable only if its element type is,
+		// whatever its length.
+		{NewArray(fn, 0), reflect.TypeOf([0]func(){})},
+		{NewArray(fn, 2), reflect.TypeOf([2]func(){})},
+		{NewArray(Types[TSTRING], 0), reflect.TypeOf([0]string{})},
+		{NewArray(mkstruct(Types[TINT8], Types[TINT64]), 3), reflect.TypeOf([3]struct {
+			a int8
+			b int64
+		}{})},
+	}
+	for _, tc := range tests {
+		if got, want := IsComparable(tc.typ), tc.rt.Comparable(); got != want {
+			t.Errorf("IsComparable(%v) = %v, but reflect reports %v.Comparable() = %v", tc.typ, got, tc.rt, want)
+		}
+	}
+}
+
+func TestAlgTypeMultiDimArray(t *testing.T) {
+	u64 := Types[TUINT64]
+	special := mkstruct(u64, Types[TSTRING])
+	tests := []struct {
+		name string
+		typ  *Type
+		want AlgKind
+	}{
+		{"[2][3]uint64", NewArray(NewArray(u64, 3), 2), AMEM},
+		{"[2][3][4]uint64", NewArray(NewArray(NewArray(u64, 4), 3), 2), AMEM},
+		{"[2][3]struct{x uint64; y string}", NewArray(NewArray(special, 3), 2), ASPECIAL},
+		{"[2][3][4]struct{x uint64; y string}", NewArray(NewArray(NewArray(special, 4), 3), 2), ASPECIAL},
+		{"[2][0]struct{x uint64; y string}", NewArray(NewArray(special, 0), 2), AMEM},
+		{"[2][1]string", NewArray(NewArray(Types[TSTRING], 1), 2), ASPECIAL},
+		{"[1][1]string", NewArray(NewArray(Types[TSTRING], 1), 1), ASTRING},
+		{"[2][3][]int", NewArray(NewArray(NewSlice(Types[TINT]), 3), 2), ANOEQ},
+	}
+	for _, tc := range tests {
+		if got := AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// Once the inner array's kind is known, arrays of it reuse it without
+	// looking at its elements again: make the element struct incomparable
+	// behind AlgType's back, and check that the outer arrays don't notice.
+	elem := mkstruct(u64, Types[TSTRING])
+	inner := NewArray(elem, 3)
+	if got := AlgType(inner); got != ASPECIAL {
+		t.Fatalf("AlgType(%v) = %v, want %v", inner, got, ASPECIAL)
+	}
+	f := elem.Field(1)
+	orig := f.Type
+	f.Type = NewSlice(Types[TINT])
+	defer func() { f.Type = orig }()
+	for _, outer := range []*Type{NewArray(inner, 2), NewArray(NewArray(inner, 2), 2)} {
+		if got := AlgType(outer); got != ASPECIAL {
+			t.Errorf("AlgType(%v) = %v, want %v computed from the cached kind of %v", outer, got, ASPECIAL, inner)
+		}
+	}
+}
+
+func TestAlgTypeError(t *testing.T) {
+	if got := AlgType(ErrorType); got != AINTER {
+		t.Errorf("AlgType(error) = %v, want %v", got, AINTER)
+	}
+	if a, helper := InterfaceCompareKind(ErrorType); a != AINTER || helper != "ifaceeq" {
+		t.Errorf("InterfaceCompareKind(error) = %v, %q, want %v, %q", a, helper, AINTER, "ifaceeq")
+	}
+	if ErrorType.IsEmptyInterface() {
+		t.Errorf("error is an empty interface, want non-empty")
+	}
+	// Comparing errors is valid, but panics if both hold the same
+	// incomparable dynamic type.
+	if !IsComparable(ErrorType) {
+		t.Errorf("IsComparable(error) = false, want true")
+	}
+	if IsSelfComparable(ErrorType) {
+		t.Errorf("IsSelfComparable(error) = true, want false")
+	}
+
+	tests := []struct {
+		name string
+		typ  *Type
+		want AlgKind
+	}{
+		{"struct{err error}", mkstruct(ErrorType), AINTER},
+		{"[1]error", NewArray(ErrorType, 1), AINTER},
+		{"[2]error", NewArray(ErrorType, 2), ASPECIAL},
+		{"struct{err error; code int}", mkstruct(ErrorType, Types[TINT]), ASPECIAL},
+	}
+	for _, tc := range tests {
+		if got := AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCanShareAlg(t *testing.T) {
+	// Instantiations with struct{ int32; string } and
+	// struct{ uint32; string } share a layout; one with
+	// struct{ int64; string } does not.
+	s32 := mkstruct(Types[TINT32], Types[TSTRING])
+	u32 := mkstruct(Types[TUINT32], Types[TSTRING])
+	s64 := mkstruct(Types[TINT64], Types[TSTRING])
+
+	tests := []struct {
+		name string
+		ts   []*Type
+		ok   bool
+		want AlgKind
+	}{
+		{"empty", nil, false, AUNK},
+		{"single", []*Type{s32}, true, ASPECIAL},
+		{"same layout", []*Type{s32, u32}, true, ASPECIAL},
+		{"different layout", []*Type{s32, s64}, false, AUNK},
+		{"mem", []*Type{Types[TINT64], Types[TUINT64]}, true, AlgType(Types[TINT64])},
+		{"different kind", []*Type{Types[TINT64], Types[TFLOAT64]}, false, AUNK},
+	}
+	for _, tc := range tests {
+		ok, a := CanShareAlg(tc.ts)
+		if ok != tc.ok || a != tc.want {
+			t.Errorf("%s: CanShareAlg = %v, %v, want %v, %v", tc.name, ok, a, tc.ok, tc.want)
+		}
+	}
+}
+
+func TestAlgString(t *testing.T) {
+	blank := NewStruct([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("x"), Types[TINT64]),
+		NewField(src.NoXPos, BlankSym, Types[TINT64]),
+	})
+	tests := []struct {
+		typ  *Type
+		want string
+	}{
+		{Types[TINT64], "MEM"},
+		{Types[TSTRING], "STRING"},
+		{Types[TINTER], "NILINTER"},
+		{NewSlice(Types[TINT]), "NOEQ"},
+		{mkstruct(Types[TINT8], Types[TINT64]), "special:padding"},
+		{mkstruct(Types[TINT64], Types[TSTRING]), "special:string-field"},
+		{mkstruct(Types[TINT64], Types[TFLOAT64]), "special:float-field"},
+		{mkstruct(Types[TINT64], ErrorType), "special:interface-field"},
+		{blank, "special:blank"},
+		{NewArray(Types[TSTRING], 2), "special:string-elem"},
+	}
+	for _, tc := range tests {
+		if got := tc.typ.AlgString(); got != tc.want {
+			t.Errorf("%v.AlgString() = %q, want %q", tc.typ, got, tc.want)
+		}
+	}
+}
+
+func TestCanReduceToMemCompare(t *testing.T) {
+	field := func(name string, t *Type) *Field {
+		sym := BlankSym
+		if name != "_" {
+			sym = LocalPkg.Lookup(name)
+		}
+		return NewField(src.NoXPos, sym, t)
+	}
+	u64 := Types[TUINT64]
+	tests := []struct {
+		name           string
+		typ            *Type
+		ok             bool
+		offset, length int64
+	}{
+		// struct{ x, y uint64; _ int }
+		{"trailing blank", NewStruct([]*Field{field("x", u64), field("y", u64), field("_", Types[TINT])}), true, 0, 16},
+		// struct{ _ int; x, y uint64 }
+		{"leading blank", NewStruct([]*Field{field("_", Types[TINT]), field("x", u64), field("y", u64)}), true, 8, 16},
+		// struct{ x uint64; y uint8 }: tail padding only.
+		{"tail padding", NewStruct([]*Field{field("x", u64), field("y", Types[TUINT8])}), true, 0, 9},
+		// struct{ x uint8; y uint64 }: padding splits the runs.
+		{"inner padding", NewStruct([]*Field{field("x", Types[TUINT8]), field("y", u64)}), false, 0, 0},
+		// struct{ x uint64; _ int; y uint64 }
+		{"inner blank", NewStruct([]*Field{field("x", u64), field("_", Types[TINT]), field("y", u64)}), false, 0, 0},
+		// struct{ x uint64; s string }
+		{"string", NewStruct([]*Field{field("x", u64), field("s", Types[TSTRING])}), false, 0, 0},
+		// struct{ x, y uint64 } is AMEM to begin with.
+		{"mem", NewStruct([]*Field{field("x", u64), field("y", u64)}), false, 0, 0},
+	}
+	for _, tc := range tests {
+		ok, off, n := CanReduceToMemCompare(tc.typ)
+		if ok != tc.ok || off != tc.offset || n != tc.length {
+			t.Errorf("%s: CanReduceToMemCompare = %v, %d, %d, want %v, %d, %d", tc.name, ok, off, n, tc.ok, tc.offset, tc.length)
+		}
+	}
+}
+
+func TestIsZeroComparable(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  *Type
+		want bool
+	}{
+		{"struct{int; int}", mkstruct(Types[TINT], Types[TINT]), true},
+		{"*int", NewPtr(Types[TINT]), true},
+		{"[4]uint8", NewArray(Types[TUINT8], 4), true},
+		{"struct{float64; float64}", mkstruct(Types[TFLOAT64], Types[TFLOAT64]), false},
+		{"struct{int; complex128}", mkstruct(Types[TINT], Types[TCOMPLEX128]), false},
+		{"struct{int8; int64}", mkstruct(Types[TINT8], Types[TINT64]), false},
+		{"string", Types[TSTRING], false},
+	}
+	for _, tc := range tests {
+		if got := IsZeroComparable(tc.typ); got != tc.want {
+			t.Errorf("IsZeroComparable(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAlgCheckUnfinishedStruct(t *testing.T) {
+	defer func(check int, h base.CountFlag, ptrSize int) {
+		base.Debug.AlgCheck, base.Flag.LowerH, PtrSize = check, h, ptrSize
+	}(base.Debug.AlgCheck, base.Flag.LowerH, PtrSize)
+	base.Debug.AlgCheck = 1
+	// With -h, Fatalf panics instead of exiting.
+	base.Flag.LowerH = 1
+
+	// A finished struct passes the check.
+	AlgType(mkstruct(Types[TINT8], Types[TINT64]))
+
+	// Before PtrSize is set, CalcSize does nothing, leaving the struct
+	// unfinished.
+	unfinished := NewStruct([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("x"), Types[TINT8]),
+		NewField(src.NoXPos, LocalPkg.Lookup("y"), Types[TINT64]),
+	})
+	PtrSize = 0
+	defer func() {
+		if recover() == nil {
+			t.Errorf("AlgType of unfinished struct did not report a fatal error")
+		}
+	}()
+	AlgType(unfinished)
+}
+
+func TestSliceElemMemComparable(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  *Type
+		want bool
+	}{
+		{"[]int", NewSlice(Types[TINT]), true},
+		{"[]string", NewSlice(Types[TSTRING]), false},
+		{"[]float64", NewSlice(Types[TFLOAT64]), false},
+		{"[]struct{int32; uint32}", NewSlice(mkstruct(Types[TINT32], Types[TUINT32])), true},
+		{"[]struct{int8; int64}", NewSlice(mkstruct(Types[TINT8], Types[TINT64])), false},
+		{"[][]int", NewSlice(NewSlice(Types[TINT])), false},
+	}
+	for _, tc := range tests {
+		if got := SliceElemMemComparable(tc.typ); got != tc.want {
+			t.Errorf("SliceElemMemComparable(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMapKeyValAlg(t *testing.T) {
+	fn := NewSignature(nil, nil, nil)
+	tests := []struct {
+		name     string
+		typ      *Type
+		key, val AlgKind
+	}{
+		{"map[string]int", NewMap(Types[TSTRING], Types[TINT]), ASTRING, AMEM},
+		{"map[struct{int64; string}]func()", NewMap(mkstruct(Types[TINT64], Types[TSTRING]), fn), ASPECIAL, ANOEQ},
+		{"map[int][]int", NewMap(Types[TINT], NewSlice(Types[TINT])), AMEM, ANOEQ},
+	}
+	for _, tc := range tests {
+		if got := MapKeyAlg(tc.typ); got != tc.key {
+			t.Errorf("MapKeyAlg(%s) = %v, want %v", tc.name, got, tc.key)
+		}
+		if got := MapValAlg(tc.typ); got != tc.val {
+			t.Errorf("MapValAlg(%s) = %v, want %v", tc.name, got, tc.val)
+		}
+	}
+}
+
+func TestMapClearNeedsGC(t *testing.T) {
+	elem := mkstruct(Types[TINT64], Types[TINT64])
+	tests := []struct {
+		name string
+		typ  *Type
+		want bool
+	}{
+		{"map[int]*T", NewMap(Types[TINT], NewPtr(elem)), true},
+		{"map[int]int", NewMap(Types[TINT], Types[TINT]), false},
+		{"map[int]T", NewMap(Types[TINT], elem), false},
+		{"map[int]string", NewMap(Types[TINT], Types[TSTRING]), true},
+		{"map[int]struct{int; []int}", NewMap(Types[TINT], mkstruct(Types[TINT], NewSlice(Types[TINT]))), true},
+		{"map[*T]int", NewMap(NewPtr(elem), Types[TINT]), false},
+		{"map[int][4]float64", NewMap(Types[TINT], NewArray(Types[TFLOAT64], 4)), false},
+		{"map[int]func()", NewMap(Types[TINT], NewSignature(nil, nil, nil)), true},
+	}
+	for _, tc := range tests {
+		if got := MapClearNeedsGC(tc.typ); got != tc.want {
+			t.Errorf("MapClearNeedsGC(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDiscriminantField(t *testing.T) {
+	field := func(name string, t *Type) *Field {
+		sym := BlankSym
+		if name != "_" {
+			sym = LocalPkg.Lookup(name)
+		}
+		return NewField(src.NoXPos, sym, t)
+	}
+	// struct{ data [8]byte; kind uint8; n int16 }
+	tagged := NewStruct([]*Field{
+		field("data", NewArray(Types[TUINT8], 8)),
+		field("kind", Types[TUINT8]),
+		field("n", Types[TINT16]),
+	})
+	// struct{ _ uint8; n uint16 }
+	blank := NewStruct([]*Field{field("_", Types[TUINT8]), field("n", Types[TUINT16])})
+	// struct{ p *int; n int64; b bool }
+	none := NewStruct([]*Field{field("p", NewPtr(Types[TINT])), field("n", Types[TINT64]), field("b", Types[TBOOL])})
+
+	tests := []struct {
+		name string
+		typ  *Type
+		want *Field
+	}{
+		{"tagged", tagged, tagged.Field(1)},
+		{"blank", blank, blank.Field(1)},
+		{"none", none, nil},
+	}
+	for _, tc := range tests {
+		if got := DiscriminantField(tc.typ); got != tc.want {
+			t.Errorf("DiscriminantField(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIncomparableArrayElem(t *testing.T) {
+	slice := NewSlice(Types[TINT])
+	m := NewMap(Types[TINT], Types[TINT])
+	tests := []struct {
+		name string
+		typ  *Type
+		want *Type
+	}{
+		{"[3][]int", NewArray(slice, 3), slice},
+		{"[3]struct{m map[int]int}", NewArray(mkstruct(m), 3), m},
+		{"[2][3][]int", NewArray(NewArray(slice, 3), 2), slice},
+		{"[2]struct{x int; s [2]struct{m map[int]int}}", NewArray(mkstruct(Types[TINT], NewArray(mkstruct(m), 2)), 2), m},
+		{"[3]int", NewArray(Types[TINT], 3), nil},
+		{"[3]struct{s string}", NewArray(mkstruct(Types[TSTRING]), 3), nil},
+	}
+	for _, tc := range tests {
+		if got := IncomparableArrayElem(tc.typ); got != tc.want {
+			t.Errorf("IncomparableArrayElem(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIsNilComparable(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  *Type
+		want bool
+	}{
+		{"*int", NewPtr(Types[TINT]), true},
+		{"unsafe.Pointer", Types[TUNSAFEPTR], true},
+		{"func()", NewSignature(nil, nil, nil), true},
+		{"map[int]int", NewMap(Types[TINT], Types[TINT]), true},
+		{"[]int", NewSlice(Types[TINT]), true},
+		{"chan int", NewChan(Types[TINT], Cboth), true},
+		{"any", Types[TINTER], true},
+		{"error", ErrorType, true},
+		{"int", Types[TINT], false},
+		{"uintptr", Types[TUINTPTR], false},
+		{"string", Types[TSTRING], false},
+		{"struct{p *int}", mkstruct(NewPtr(Types[TINT])), false},
+		{"[1]*int", NewArray(NewPtr(Types[TINT]), 1), false},
+	}
+	for _, tc := range tests {
+		if got := IsNilComparable(tc.typ); got != tc.want {
+			t.Errorf("IsNilComparable(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAlgSignature(t *testing.T) {
+	named := func(name string, underlying *Type) *Type {
+		t := mknamed(name)
+		t.SetUnderlying(underlying)
+		return t
+	}
+	// type A struct{ f0 int8; f1 int64; f2 string }
+	// type B struct{ f0 uint8; f1 uint64; f2 string }
+	a := named("A", mkstruct(Types[TINT8], Types[TINT64], Types[TSTRING]))
+	b := named("B", mkstruct(Types[TUINT8], Types[TUINT64], Types[TSTRING]))
+	if sa, sb := AlgSignature(a), AlgSignature(b); sa != sb {
+		t.Errorf("AlgSignature(A) = %q, AlgSignature(B) = %q, want equal", sa, sb)
+	}
+	if got, want := AlgSignature(a), "struct{0:MEM1;8:MEM8;16:STRING16}32"; got != want {
+		t.Errorf("AlgSignature(A) = %q, want %q", got, want)
+	}
+
+	// Padded and blank variants of A all differ from A and each other.
+	variants := []*Type{
+		a,
+		// struct{ f0 int16; f1 int64; f2 string }
+		mkstruct(Types[TINT16], Types[TINT64], Types[TSTRING]),
+		// struct{ f0 int8; _ [7]byte; f1 int64; f2 string }
+		NewStruct([]*Field{
+			NewField(src.NoXPos, LocalPkg.Lookup("f0"), Types[TINT8]),
+			NewField(src.NoXPos, BlankSym, NewArray(Types[TUINT8], 7)),
+			NewField(src.NoXPos, LocalPkg.Lookup("f1"), Types[TINT64]),
+			NewField(src.NoXPos, LocalPkg.Lookup("f2"), Types[TSTRING]),
+		}),
+		// struct{ f0 int8; f1 int64; f2 string; f3 int8 }
+		mkstruct(Types[TINT8], Types[TINT64], Types[TSTRING], Types[TINT8]),
+		NewArray(a, 2),
+	}
+	seen := map[string]int{}
+	for i, v := range variants {
+		sig := AlgSignature(v)
+		if j, ok := seen[sig]; ok {
+			t.Errorf("variants %d and %d have the same signature %q", j, i, sig)
+		}
+		seen[sig] = i
+	}
+
+	if got, want := AlgSignature(mkstruct(Types[TINT64], Types[TINT64])), "MEM16"; got != want {
+		t.Errorf("AlgSignature(struct{int64; int64}) = %q, want %q", got, want)
+	}
+}
+
+func TestNoEqReason(t *testing.T) {
+	fn := NewSignature(nil, nil, nil)
+	m := NewMap(Types[TINT], Types[TINT])
+	slice := NewSlice(Types[TINT])
+	tests := []struct {
+		name string
+		typ  *Type
+		want string
+	}{
+		{"func()", fn, "func"},
+		{"map[int]int", m, "map"},
+		{"[]int", slice, "slice"},
+		{"[2]map[int]int", NewArray(m, 2), "map"},
+		{"struct{int; []int; func()}", mkstruct(Types[TINT], slice, fn), "slice"},
+		{"struct{struct{func()}}", mkstruct(mkstruct(fn)), "func"},
+		{"[0]func()", NewArray(fn, 0), "func"},
+		{"int", Types[TINT], ""},
+		{"struct{int8; string}", mkstruct(Types[TINT8], Types[TSTRING]), ""},
+	}
+	for _, tc := range tests {
+		if got := NoEqReason(tc.typ); got != tc.want {
+			t.Errorf("NoEqReason(%s) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAlgTypeConcurrent(t *testing.T) {
+	typ := nestedStruct(8)
+	want := AlgType(typ)
+	var components []*Type
+	WalkAlg(typ, func(path []*Field, elemIndex int, a AlgKind) {
+		if len(path) > 0 {
+			components = append(components, path[len(path)-1].Type)
+		}
+	})
+
+	// As in the backend, sizes are computed before compiling
+	// concurrently and may not be computed while doing so.
+	CalcSizeDisabled = true
+	defer func() { CalcSizeDisabled = false }()
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 16)
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if got := AlgType(typ); got != want {
+					errs <- fmt.Sprintf("AlgType = %v, want %v", got, want)
+					return
+				}
+				for _, c := range components {
+					AlgType(c)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestFirstMemField(t *testing.T) {
+	u64 := Types[TUINT64]
+	blank := NewStruct([]*Field{
+		NewField(src.NoXPos, BlankSym, Types[TINT32]),
+		NewField(src.NoXPos, LocalPkg.Lookup("x"), Types[TINT32]),
+		NewField(src.NoXPos, LocalPkg.Lookup("s"), Types[TSTRING]),
+	})
+	tests := []struct {
+		name           string
+		typ            *Type
+		offset, length int64
+		ok             bool
+	}{
+		{"struct{uint64; string}", mkstruct(u64, Types[TSTRING]), 0, 8, true},
+		{"struct{uint64; uint64; string}", mkstruct(u64, u64, Types[TSTRING]), 0, 16, true},
+		{"struct{int8; int64; string}", mkstruct(Types[TINT8], Types[TINT64], Types[TSTRING]), 0, 1, true},
+		{"struct{_ int32; x int32; s string}", blank, 4, 4, true},
+		{"struct{string; uint64}", mkstruct(Types[TSTRING], u64), 0, 0, false},
+		{"struct{any; uint64}", mkstruct(Types[TINTER], u64), 0, 0, false},
+		{"struct{float64; uint64}", mkstruct(Types[TFLOAT64], u64), 0, 0, false},
+		{"struct{}", mkstruct(), 0, 0, false},
+	}
+	for _, tc := range tests {
+		off, n, ok := FirstMemField(tc.typ)
+		if off != tc.offset || n != tc.length || ok != tc.ok {
+			t.Errorf("FirstMemField(%s) = %d, %d, %v, want %d, %d, %v", tc.name, off, n, ok, tc.offset, tc.length, tc.ok)
+		}
+	}
+}
+
+func TestAlgTypeAllBlank(t *testing.T) {
+	// struct{ _ int; _ string }
+	allBlank := NewStruct([]*Field{
+		NewField(src.NoXPos, BlankSym, Types[TINT]),
+		NewField(src.NoXPos, BlankSym, Types[TSTRING]),
+	})
+	// Blank fields never take part in comparison, so all values of an
+	// all-blank struct are equal. The struct still occupies memory that
+	// must not be compared, so it is ASPECIAL, not memory-comparable,
+	// and its generated functions compare and hash nothing.
+	if got := AlgType(allBlank); got != ASPECIAL {
+		t.Errorf("AlgType(%v) = %v, want %v", allBlank, got, ASPECIAL)
+	}
+	if runs := MemCompareRuns(allBlank); len(runs) != 0 {
+		t.Errorf("MemCompareRuns(%v) = %v, want none", allBlank, runs)
+	}
+	WalkAlg(allBlank, func(path []*Field, elemIndex int, a AlgKind) {
+		if f := path[len(path)-1]; !f.Sym.IsBlank() {
+			t.Errorf("WalkAlg(%v) visited non-blank field %v", allBlank, f.Sym)
+		}
+	})
+
+	// struct{ _ int; x int; _ string }
+	mixed := NewStruct([]*Field{
+		NewField(src.NoXPos, BlankSym, Types[TINT]),
+		NewField(src.NoXPos, LocalPkg.Lookup("x"), Types[TINT]),
+		NewField(src.NoXPos, BlankSym, Types[TSTRING]),
+	})
+	if got := AlgType(mixed); got != ASPECIAL {
+		t.Errorf("AlgType(%v) = %v, want %v", mixed, got, ASPECIAL)
+	}
+	if ok, off, n := CanReduceToMemCompare(mixed); !ok || off != 8 || n != 8 {
+		t.Errorf("CanReduceToMemCompare(%v) = %v, %d, %d, want true, 8, 8", mixed, ok, off, n)
+	}
+}
+
+func TestAlgCompatibleForCompare(t *testing.T) {
+	named := func(name string, underlying *Type) *Type {
+		t := mknamed(name)
+		t.SetUnderlying(underlying)
+		return t
+	}
+	pair := mkstruct(Types[TINT64], Types[TSTRING])
+	tests := []struct {
+		name string
+		a, b *Type
+		want bool
+	}{
+		{"T and its underlying struct", named("T", pair), pair, true},
+		{"U and its underlying array", named("U", NewArray(pair, 3)), NewArray(pair, 3), true},
+		{"MyInt and int", named("MyInt", Types[TINT]), Types[TINT], true},
+		{"struct{int64; string} and struct{int32; string}", pair, mkstruct(Types[TINT32], Types[TSTRING]), false},
+		{"[3]string and [4]string", NewArray(Types[TSTRING], 3), NewArray(Types[TSTRING], 4), false},
+	}
+	for _, tc := range tests {
+		if got := AlgCompatibleForCompare(tc.a, tc.b); got != tc.want {
+			t.Errorf("AlgCompatibleForCompare(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAlgTypeIterator(t *testing.T) {
+	// func(yield func(int) bool)
+	yield := NewSignature(nil, []*Field{NewField(src.NoXPos, nil, Types[TINT])}, []*Field{NewField(src.NoXPos, nil, Types[TBOOL])})
+	seq := NewSignature(nil, []*Field{NewField(src.NoXPos, nil, yield)}, nil)
+	if got := AlgType(seq); got != ANOEQ {
+		t.Errorf("AlgType(%v) = %v, want %v", seq, got, ANOEQ)
+	}
+	if got := NoEqReason(seq); got != "func" {
+		t.Errorf("NoEqReason(%v) = %q, want %q", seq, got, "func")
+	}
+
+	// struct{ f0 string; f1 func(func(int) bool) }
+	s := mkstruct(Types[TSTRING], seq)
+	if got := AlgType(s); got != ANOEQ {
+		t.Errorf("AlgType(%v) = %v, want %v", s, got, ANOEQ)
+	}
+	if f := IncomparableField(s); f != s.Field(1) {
+		t.Errorf("IncomparableField(%v) = %v, want %v", s, f, s.Field(1))
+	}
+	if f := MapKeyViolation(s); f != nil {
+		t.Errorf("MapKeyViolation(%v) = %v, want nil", s, f.Sym)
+	}
+}
+
+func TestKeyCompareClass(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  *Type
+		want KeyClass
+	}{
+		{"int", Types[TINT], KeyClassMemSmall},
+		{"*int", NewPtr(Types[TINT]), KeyClassMemSmall},
+		{"[64]byte", NewArray(Types[TUINT8], 64), KeyClassMemLarge},
+		{"struct{int64; int64}", mkstruct(Types[TINT64], Types[TINT64]), KeyClassMemLarge},
+		{"string", Types[TSTRING], KeyClassString},
+		{"struct{s string}", mkstruct(Types[TSTRING]), KeyClassString},
+		{"struct{int8; int64}", mkstruct(Types[TINT8], Types[TINT64]), KeyClassSpecial},
+		{"float64", Types[TFLOAT64], KeyClassSpecial},
+		{"any", Types[TINTER], KeyClassSpecial},
+	}
+	for _, tc := range tests {
+		if got := KeyCompareClass(tc.typ); got != tc.want {
+			t.Errorf("KeyCompareClass(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSelfCompareViolation(t *testing.T) {
+	anyType := Types[TINTER]
+	inner := mkstruct(Types[TINT], anyType)
+	blankFirst := NewStruct([]*Field{
+		NewField(src.NoXPos, BlankSym, anyType),
+		NewField(src.NoXPos, LocalPkg.Lookup("x"), ErrorType),
+	})
+	tests := []struct {
+		name string
+		typ  *Type
+		want *Field
+	}{
+		{"struct{int; any}", inner, inner.Field(1)},
+		{"struct{string; [2]struct{int; any}}", mkstruct(Types[TSTRING], NewArray(inner, 2)), inner.Field(1)},
+		{"[3]struct{int; any}", NewArray(inner, 3), inner.Field(1)},
+		{"struct{_ any; x error}", blankFirst, blankFirst.Field(1)},
+		{"any", anyType, nil},
+		{"[2]any", NewArray(anyType, 2), nil},
+		{"struct{int; string}", mkstruct(Types[TINT], Types[TSTRING]), nil},
+	}
+	for _, tc := range tests {
+		if got := SelfCompareViolation(tc.typ); got != tc.want {
+			t.Errorf("SelfCompareViolation(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSliceHeaderCompareKind(t *testing.T) {
+	for _, elem := range []*Type{Types[TUINT8], Types[TINT64], Types[TSTRING], NewArray(Types[TINT], 4)} {
+		s := NewSlice(elem)
+		if got := SliceHeaderCompareKind(s); got != ANOEQ {
+			t.Errorf("SliceHeaderCompareKind(%v) = %v, want %v", s, got, ANOEQ)
+		}
+		if got := AlgType(s); got != SliceHeaderCompareKind(s) {
+			t.Errorf("AlgType(%v) = %v, want %v", s, got, ANOEQ)
+		}
+	}
+}
+
+// TestAlgTypeNestedSlice checks that a slice makes every enclosing
+// struct and array ANOEQ, however deeply it is nested and whatever
+// comparable components surround it.
+func TestAlgTypeNestedSlice(t *testing.T) {
+	slice := NewSlice(Types[TINT])
+	blankInt := NewStruct([]*Field{
+		NewField(src.NoXPos, BlankSym, Types[TINT]),
+		NewField(src.NoXPos, LocalPkg.Lookup("s"), slice),
+	})
+	CalcSize(blankInt)
+	tests := []struct {
+		name string
+		typ  *Type
+	}{
+		{"struct{[]int}", mkstruct(slice)},
+		{"struct{int; []int}", mkstruct(Types[TINT], slice)},
+		{"struct{[]int; string}", mkstruct(slice, Types[TSTRING])},
+		{"struct{int; struct{[]int}}", mkstruct(Types[TINT], mkstruct(slice))},
+		{"struct{struct{struct{float64; []int}}}", mkstruct(mkstruct(mkstruct(Types[TFLOAT64], slice)))},
+		{"[2][]int", NewArray(slice, 2)},
+		{"[0][]int", NewArray(slice, 0)},
+		{"[3]struct{int; []int}", NewArray(mkstruct(Types[TINT], slice), 3)},
+		{"struct{[2][]int}", mkstruct(NewArray(slice, 2))},
+		{"struct{int; [2]struct{string; []int}}", mkstruct(Types[TINT], NewArray(mkstruct(Types[TSTRING], slice), 2))},
+		{"[2][3]struct{struct{[]int}}", NewArray(NewArray(mkstruct(mkstruct(slice)), 3), 2)},
+		{"struct{_ int; s []int}", blankInt},
+	}
+	for _, tc := range tests {
+		if got := AlgType(tc.typ); got != ANOEQ {
+			t.Errorf("AlgType(%s) = %v, want %v", tc.name, got, ANOEQ)
+		}
+		if got := NoEqReason(tc.typ); got != "slice" {
+			t.Errorf("NoEqReason(%s) = %q, want \"slice\"", tc.name, got)
+		}
+		if IsComparable(tc.typ) {
+			t.Errorf("IsComparable(%s) = true, want false", tc.name)
+		}
+	}
+}
+
+// TestAlgTypeAnonymousStruct checks that anonymous struct types, such
+// as those in type switch cases and composite literals, get the same
+// algorithm as a named type with the same layout, and that two distinct
+// anonymous types with identical layouts each get diagnostics naming
+// their own fields.
+func TestAlgTypeAnonymousStruct(t *testing.T) {
+	mk := func(names ...string) *Type {
+		fields := []*Field{
+			NewField(src.NoXPos, LocalPkg.Lookup(names[0]), Types[TINT64]),
+			NewField(src.NoXPos, LocalPkg.Lookup(names[1]), Types[TSTRING]),
+		}
+		typ := NewStruct(fields)
+		CalcSize(typ)
+		return typ
+	}
+	a, b, c := mk("x", "s"), mk("x", "s"), mk("y", "t")
+	if a == b || !Identical(a, b) {
+		t.Fatalf("want distinct but identical anonymous types %v and %v", a, b)
+	}
+	named := mknamed("Named")
+	named.SetUnderlying(a)
+
+	for _, typ := range []*Type{a, b, c, named} {
+		if got := AlgType(typ); got != ASPECIAL {
+			t.Errorf("AlgType(%v) = %v, want %v", typ, got, ASPECIAL)
+		}
+		if got, want := typ.AlgString(), "special:string-field"; got != want {
+			t.Errorf("AlgString(%v) = %q, want %q", typ, got, want)
+		}
+	}
+	if got, want := AlgSignature(a), AlgSignature(c); got != want {
+		t.Errorf("AlgSignature(%v) = %q, AlgSignature(%v) = %q, want equal", a, got, c, want)
+	}
+	if got, want := SpecialReason(a), "non-memory field s"; got != want {
+		t.Errorf("SpecialReason(%v) = %q, want %q", a, got, want)
+	}
+	if got, want := SpecialReason(c), "non-memory field t"; got != want {
+		t.Errorf("SpecialReason(%v) = %q, want %q", c, got, want)
+	}
+
+	mem1 := mkstruct(Types[TINT32], Types[TINT32])
+	mem2 := mkstruct(Types[TINT32], Types[TINT32])
+	namedMem := mknamed("Pair")
+	namedMem.SetUnderlying(mem1)
+	for _, typ := range []*Type{mem1, mem2, namedMem} {
+		if got := AlgType(typ); got != AMEM {
+			t.Errorf("AlgType(%v) = %v, want %v", typ, got, AMEM)
+		}
+	}
+	if !EqualAlg(mem2, namedMem) {
+		t.Errorf("EqualAlg(%v, %v) = false, want true", mem2, namedMem)
+	}
+}
+
+func TestShareEqClosure(t *testing.T) {
+	special1 := mkstruct(Types[TINT64], Types[TSTRING])
+	special2 := mkstruct(Types[TINT64], Types[TSTRING])
+	named := mknamed("S")
+	named.SetUnderlying(special1)
+	padded := mkstruct(Types[TINT32], Types[TSTRING])
+	mem := mkstruct(Types[TINT64], Types[TINT64])
+	tests := []struct {
+		name string
+		a, b *Type
+		want bool
+	}{
+		{"identical special structs", special1, special2, true},
+		{"named and anonymous special structs", named, special2, true},
+		{"same type", special1, special1, true},
+		{"special structs with different layouts", special1, padded, false},
+		{"special and mem structs", special1, mem, false},
+		{"mem and special structs", mem, special1, false},
+		{"identical mem structs", mem, mkstruct(Types[TINT64], Types[TINT64]), false},
+		{"special struct and string", special1, Types[TSTRING], false},
+	}
+	for _, tc := range tests {
+		if got := ShareEqClosure(tc.a, tc.b); got != tc.want {
+			t.Errorf("%s: ShareEqClosure(%v, %v) = %v, want %v", tc.name, tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestPaddingKind(t *testing.T) {
+	mk := func(fields ...*Field) *Type {
+		typ := NewStruct(fields)
+		CalcSize(typ)
+		return typ
+	}
+	named := func(name string, typ *Type) *Field {
+		return NewField(src.NoXPos, LocalPkg.Lookup(name), typ)
+	}
+	blank := func(typ *Type) *Field {
+		return NewField(src.NoXPos, BlankSym, typ)
+	}
+	i8, i32, i64 := Types[TINT8], Types[TINT32], Types[TINT64]
+
+	tests := []struct {
+		name string
+		typ  *Type
+		i    int
+		want PadKind
+	}{
+		{"struct{a int8; b int64}, a", mk(named("a", i8), named("b", i64)), 0, PadAlignment},
+		{"struct{a int8; _ [7]byte; b int64}, a", mk(named("a", i8), blank(NewArray(i8, 7)), named("b", i64)), 0, PadTrailingBlank},
+		{"struct{a int8; _ int8; b int32}, a", mk(named("a", i8), blank(i8), named("b", i32)), 0, PadAlignment},
+		{"struct{a int64; b int8}, b", mk(named("a", i64), named("b", i8)), 1, PadAlignment},
+		{"struct{a int32; _ int32}, a", mk(named("a", i32), blank(i32)), 0, PadTrailingBlank},
+		{"struct{a int32; _ int32; _ int64}, a", mk(named("a", i32), blank(i32), blank(i64)), 0, PadTrailingBlank},
+		{"struct{a int32; b int32}, a", mk(named("a", i32), named("b", i32)), 0, PadNone},
+		{"struct{a int32; _ [0]int32; b int32}, a", mk(named("a", i32), blank(NewArray(i32, 0)), named("b", i32)), 0, PadNone},
+		{"struct{a int64}, a", mk(named("a", i64)), 0, PadNone},
+	}
+	for _, tc := range tests {
+		if got := PaddingKind(tc.typ, tc.i); got != tc.want {
+			t.Errorf("PaddingKind(%s, %d) = %v, want %v", tc.name, tc.i, got, tc.want)
+		}
+	}
+}
+
+// TestAlgTypeComparableConstraint pins the behavior of the alg functions
+// on a method-less interface type named comparable, the closest the
+// compiler's types come to the comparable constraint.
+func TestAlgTypeComparableConstraint(t *testing.T) {
+	comparable := mknamed("comparable")
+	comparable.SetUnderlying(NewInterface(nil))
+	CalcSize(comparable)
+	for _, typ := range []*Type{comparable, mkstruct(comparable), NewArray(comparable, 2)} {
+		if !IsComparable(typ) {
+			t.Errorf("IsComparable(%v) = false, want true", typ)
+		}
+		if IsSelfComparable(typ) {
+			t.Errorf("IsSelfComparable(%v) = true, want false", typ)
+		}
+	}
+	if got := AlgType(comparable); got != ANILINTER {
+		t.Errorf("AlgType(comparable) = %v, want %v", got, ANILINTER)
+	}
+	if got := AlgType(mkstruct(Types[TINT], comparable)); got != ASPECIAL {
+		t.Errorf("AlgType(struct{int; comparable}) = %v, want %v", got, ASPECIAL)
+	}
+	if !EqualAlg(comparable, AnyType) {
+		t.Errorf("EqualAlg(comparable, any) = false, want true")
+	}
+}
+
+func TestAlgNoMem(t *testing.T) {
+	mem := mkstruct(Types[TINT64], Types[TINT64])
+	arr := NewArray(Types[TINT32], 4)
+	padded := mkstruct(Types[TINT8], Types[TINT64])
+
+	AlgOverride[AMEM] = ASPECIAL
+	defer delete(AlgOverride, AMEM)
+	base.Debug.AlgNoMem = 1
+	defer func() { base.Debug.AlgNoMem = 0 }()
+	tests := []struct {
+		name string
+		typ  *Type
+		want AlgKind
+	}{
+		{"struct{int64; int64}", mem, ASPECIAL},
+		{"[4]int32", arr, ASPECIAL},
+		{"[2]struct{int64; int64}", NewArray(mem, 2), ASPECIAL},
+		{"struct{int8; int64}", padded, ASPECIAL},
+		{"[]int", NewSlice(Types[TINT]), ANOEQ},
+		// Zero-size types and scalars are not affected.
+		{"[0]int", NewArray(Types[TINT], 0), AMEM},
+		{"struct{}", mkstruct(), AMEM},
+		{"int64", Types[TINT64], AMEM},
+		{"*int", NewPtr(Types[TINT]), AMEM},
+		{"string", Types[TSTRING], ASTRING},
+		{"float64", Types[TFLOAT64], AFLOAT64},
+	}
+	for _, tc := range tests {
+		if got := AlgType(tc.typ); got != tc.want {
+			t.Errorf("AlgType(%s) = %v under -d=algnomem, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestAlgTypeBeforeLayout checks that AlgType computed before t's layout
+// is final does not stick: the kind reported once the layout is final,
+// or after it is invalidated and recomputed, is authoritative.
+func TestAlgTypeBeforeLayout(t *testing.T) {
+	s := NewStruct([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("x"), Types[TINT8]),
+		NewField(src.NoXPos, LocalPkg.Lookup("y"), Types[TINT64]),
+	})
+
+	// Before PtrSize is set, CalcSize does nothing.
+	ptrSize := PtrSize
+	PtrSize = 0
+	got := AlgType(s)
+	PtrSize = ptrSize
+	if got != AUNK {
+		t.Errorf("AlgType(%v) before layout = %v, want %v", s, got, AUNK)
+	}
+	if s.widthCalculated() {
+		t.Fatalf("AlgType(%v) before layout computed its width", s)
+	}
+
+	if got := AlgType(s); got != ASPECIAL {
+		t.Errorf("AlgType(%v) after layout = %v, want %v", s, got, ASPECIAL)
+	}
+	if got := s.Field(1).Offset; got != 8 {
+		t.Errorf("offset of %v.y = %d, want 8", s, got)
+	}
+
+	// Relaying s out replaces the kind and drops its stale record.
+	algRecords[s] = algRecord{Name: "s", Kind: s.alg.String()}
+	InvalidateAlg(s)
+	if _, ok := algRecords[s]; ok {
+		t.Errorf("InvalidateAlg(%v) kept its -d=algjson record", s)
+	}
+	s.setFields([]*Field{
+		NewField(src.NoXPos, LocalPkg.Lookup("x"), Types[TINT64]),
+		NewField(src.NoXPos, LocalPkg.Lookup("y"), Types[TINT64]),
+	})
+	if got := AlgType(s); got != AMEM {
+		t.Errorf("AlgType(%v) after relayout = %v, want %v", s, got, AMEM)
+	}
+}
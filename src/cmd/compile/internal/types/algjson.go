@@ -0,0 +1,69 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"cmd/compile/internal/base"
+)
+
+// An algRecord describes the algorithm chosen for a named type, as
+// written to the -d=algjson file.
+type algRecord struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Size    int64  `json:"size"`
+	Padding int64  `json:"padding"`
+	Reason  string `json:"special-reason,omitempty"`
+}
+
+// algRecords holds the records collected under -d=algjson, indexed by
+// type. The flag disables concurrent compilation, so no locking is
+// needed.
+var algRecords = map[*Type]algRecord{}
+
+// recordAlg records the AlgKind of named type t for -d=algjson.
+func recordAlg(t *Type) {
+	if !t.Pos().IsKnown() {
+		return
+	}
+	if _, ok := algRecords[t]; ok {
+		return
+	}
+	r := algRecord{
+		Name: t.LinkString(),
+		Kind: t.alg.String(),
+		Size: t.width,
+	}
+	if t.IsStruct() {
+		r.Padding = PaddingBytes(t)
+		if t.alg == ASPECIAL {
+			r.Reason = specialReason(t)
+		}
+	}
+	algRecords[t] = r
+}
+
+// WriteAlgJSON writes the records collected under -d=algjson to file,
+// as a JSON array sorted by type name.
+func WriteAlgJSON(file string) {
+	records := make([]algRecord, 0, len(algRecords))
+	for _, r := range algRecords {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Name < records[j].Name
+	})
+	data, err := json.MarshalIndent(records, "", "\t")
+	if err != nil {
+		base.Fatalf("encoding alg records: %v", err)
+	}
+	if err := os.WriteFile(file, append(data, '\n'), 0666); err != nil {
+		base.Fatalf("writing alg records: %v", err)
+	}
+}
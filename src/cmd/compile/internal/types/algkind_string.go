@@ -18,19 +18,23 @@ func _() {
 	_ = x[AMEM32-7]
 	_ = x[AMEM64-8]
 	_ = x[AMEM128-9]
-	_ = x[ASTRING-10]
-	_ = x[AINTER-11]
-	_ = x[ANILINTER-12]
-	_ = x[AFLOAT32-13]
-	_ = x[AFLOAT64-14]
-	_ = x[ACPLX64-15]
-	_ = x[ACPLX128-16]
-	_ = x[ASPECIAL-17]
+	_ = x[AMEM256-10]
+	_ = x[ASTRING-11]
+	_ = x[AINTER-12]
+	_ = x[ANILINTER-13]
+	_ = x[AFLOAT32-14]
+	_ = x[AFLOAT64-15]
+	_ = x[ACPLX64-16]
+	_ = x[ACPLX128-17]
+	_ = x[AFLOAT32x4-18]
+	_ = x[AFLOAT64x2-19]
+	_ = x[AFLOAT64x4-20]
+	_ = x[ASPECIAL-21]
 }
 
-const _AlgKind_name = "UNKNOEQNOALGMEMMEM0MEM8MEM16MEM32MEM64MEM128STRINGINTERNILINTERFLOAT32FLOAT64CPLX64CPLX128SPECIAL"
+const _AlgKind_name = "UNKNOEQNOALGMEMMEM0MEM8MEM16MEM32MEM64MEM128MEM256STRINGINTERNILINTERFLOAT32FLOAT64CPLX64CPLX128FLOAT32x4FLOAT64x2FLOAT64x4SPECIAL"
 
-var _AlgKind_index = [...]uint8{0, 3, 7, 12, 15, 19, 23, 28, 33, 38, 44, 50, 55, 63, 70, 77, 83, 90, 97}
+var _AlgKind_index = [...]uint8{0, 3, 7, 12, 15, 19, 23, 28, 33, 38, 44, 50, 56, 61, 69, 76, 83, 89, 96, 105, 114, 123, 130}
 
 func (i AlgKind) String() string {
 	if i < 0 || i >= AlgKind(len(_AlgKind_index)-1) {
@@ -336,6 +336,11 @@ func CalcSize(t *Type) {
 		t.ptrBytes = int64(2 * PtrSize)
 
 	case TCHAN: // implemented as pointer
+		// Channels of every direction compare as pointers,
+		// but make sure t was fully constructed.
+		if t.Elem() == nil || t.ChanDir()&Cboth == 0 {
+			base.Fatalf("CalcSize: incomplete channel type %v", t)
+		}
 		w = int64(PtrSize)
 		t.intRegs = 1
 		t.ptrBytes = int64(PtrSize)
@@ -415,6 +420,9 @@ func CalcSize(t *Type) {
 		}
 		switch a := t.Elem().alg; a {
 		case AMEM, ANOEQ, ANOALG:
+			// An array of plain memory is plain memory, whatever its
+			// length; reflectdata.AlgType picks a fixed-width variant
+			// if the total size allows.
 			t.setAlg(a)
 		default:
 			switch t.NumElem() {
@@ -557,16 +565,25 @@ func CalcStructSize(t *Type) {
 		t.setAlg(ANOALG)
 	}
 	if len(fields) == 1 && !fields[0].Sym.IsBlank() {
-		// One-field struct is same as that one field alone.
-		t.setAlg(fields[0].Type.alg)
+		// One-field struct is same as that one field alone,
+		// except that a memory compare must not read any
+		// trailing padding.
+		a := fields[0].Type.alg
+		if a == AMEM && HasPadding(t) {
+			a = ASPECIAL
+		}
+		t.setAlg(a)
 	} else {
-		for i, f := range fields {
+		// A struct whose fields are all unpadded, non-blank AMEM stays
+		// AMEM, which lets reflectdata.AlgType treat it as a single
+		// fixed-width memory comparison.
+		for _, f := range fields {
 			a := f.Type.alg
 			switch a {
 			case ANOEQ, ANOALG:
 			case AMEM:
-				// Blank fields and padded fields need a special compare.
-				if f.Sym.IsBlank() || IsPaddedField(t, i) {
+				// Blank fields need a special compare.
+				if f.Sym.IsBlank() {
 					a = ASPECIAL
 				}
 			default:
@@ -575,6 +592,10 @@ func CalcStructSize(t *Type) {
 			}
 			t.setAlg(a)
 		}
+		// Padded fields need a special compare too.
+		if HasPadding(t) {
+			t.setAlg(ASPECIAL)
+		}
 	}
 	// Compute ptrBytes.
 	for i := len(fields) - 1; i >= 0; i-- {
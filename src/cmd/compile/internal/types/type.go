@@ -113,7 +113,9 @@ var (
 	ByteType *Type
 	RuneType *Type
 
-	// Predeclared error interface type.
+	// Predeclared error interface type. It has a method, so its values
+	// are compared like those of other non-empty interfaces (AINTER),
+	// not like those of any (ANILINTER).
 	ErrorType *Type
 	// Predeclared comparable interface type.
 	ComparableType *Type
@@ -105,6 +105,9 @@ func walkCompare(n *ir.BinaryExpr, init *ir.Nodes) ir.Node {
 	// While we're here, decide whether to
 	// inline or call an eq alg.
 	t := n.X.Type()
+	if (t.IsArray() || t.IsStruct()) && !types.AlgCompatibleForCompare(t, n.Y.Type()) {
+		base.Fatalf("comparison of %v and %v with incompatible algorithms", t, n.Y.Type())
+	}
 	var inline bool
 
 	maxcmpsize := int64(4)
@@ -169,6 +172,12 @@ func walkCompare(n *ir.BinaryExpr, init *ir.Nodes) ir.Node {
 	case types.TSTRUCT:
 		inline = compare.EqStructCost(t) <= 4
 	}
+	if !types.NeedsGeneratedEq(t) && !compare.IsRegularMemory(t) {
+		// EqFor has nothing to call for types like small float
+		// arrays, which the runtime compares only through their
+		// equality closures, so compare them inline.
+		inline = true
+	}
 
 	cmpl := n.X
 	for cmpl != nil && cmpl.Op() == ir.OCONVNOP {
@@ -189,7 +198,9 @@ func walkCompare(n *ir.BinaryExpr, init *ir.Nodes) ir.Node {
 		// Should only arrive here with large memory or
 		// a struct/array containing a non-memory field/element.
 		// Small memory is handled inline, and single non-memory
-		// is handled by walkCompare.
+		// is handled by walkCompare. EqFor calls the generated
+		// function when types.NeedsGeneratedEq says there is one,
+		// and memequal otherwise.
 		fn, needsLength := reflectdata.EqFor(t)
 		call := ir.NewCallExpr(base.Pos, ir.OCALL, fn, nil)
 		call.Args.Append(typecheck.NodAddr(cmpl))
@@ -242,9 +253,11 @@ func walkCompare(n *ir.BinaryExpr, init *ir.Nodes) ir.Node {
 	} else {
 		step := int64(1)
 		remains := t.NumElem() * t.Elem().Size()
-		combine64bit := unalignedLoad && types.RegSize == 8 && t.Elem().Size() <= 4 && t.Elem().IsInteger()
-		combine32bit := unalignedLoad && t.Elem().Size() <= 2 && t.Elem().IsInteger()
-		combine16bit := unalignedLoad && t.Elem().Size() == 1 && t.Elem().IsInteger()
+		// -d=algnomem compares elements one by one.
+		combine := unalignedLoad && base.Debug.AlgNoMem == 0
+		combine64bit := combine && types.RegSize == 8 && t.Elem().Size() <= 4 && t.Elem().IsInteger()
+		combine32bit := combine && t.Elem().Size() <= 2 && t.Elem().IsInteger()
+		combine16bit := combine && t.Elem().Size() == 1 && t.Elem().IsInteger()
 		for i := int64(0); remains > 0; {
 			var convType *types.Type
 			switch {
@@ -187,7 +187,11 @@ func mapfast(t *types.Type) int {
 	if t.Elem().Size() > abi.MapMaxElemBytes {
 		return mapslow
 	}
-	switch reflectdata.AlgType(t.Key()) {
+	// The fast routines hash keys with a runtime hasher.
+	if types.NeedsGeneratedHash(t.Key()) {
+		return mapslow
+	}
+	switch reflectdata.KeyHashKind(t.Key()) {
 	case types.AMEM32:
 		if !t.Key().HasPointers() {
 			return mapfast32
@@ -147,10 +147,14 @@ var builtins = [...]struct {
 	{"runtime.memequal32", 1},
 	{"runtime.memequal64", 1},
 	{"runtime.memequal128", 1},
+	{"runtime.memequal256", 1},
 	{"runtime.f32equal", 1},
 	{"runtime.f64equal", 1},
 	{"runtime.c64equal", 1},
 	{"runtime.c128equal", 1},
+	{"runtime.f32x4equal", 1},
+	{"runtime.f64x2equal", 1},
+	{"runtime.f64x4equal", 1},
 	{"runtime.strequal", 1},
 	{"runtime.interequal", 1},
 	{"runtime.nilinterequal", 1},
@@ -161,10 +165,14 @@ var builtins = [...]struct {
 	{"runtime.memhash32", 1},
 	{"runtime.memhash64", 1},
 	{"runtime.memhash128", 1},
+	{"runtime.memhash256", 1},
 	{"runtime.f32hash", 1},
 	{"runtime.f64hash", 1},
 	{"runtime.c64hash", 1},
 	{"runtime.c128hash", 1},
+	{"runtime.f32x4hash", 1},
+	{"runtime.f64x2hash", 1},
+	{"runtime.f64x4hash", 1},
 	{"runtime.strhash", 1},
 	{"runtime.interhash", 1},
 	{"runtime.nilinterhash", 1},
@@ -32,6 +32,10 @@ func memhash128(p unsafe.Pointer, h uintptr) uintptr {
 	return memhash(p, h, 16)
 }
 
+func memhash256(p unsafe.Pointer, h uintptr) uintptr {
+	return memhash(p, h, 32)
+}
+
 //go:nosplit
 func memhash_varlen(p unsafe.Pointer, h uintptr) uintptr {
 	ptr := getclosureptr()
@@ -123,6 +127,30 @@ func c128hash(p unsafe.Pointer, h uintptr) uintptr {
 	return f64hash(unsafe.Pointer(&x[1]), f64hash(unsafe.Pointer(&x[0]), h))
 }
 
+// f32x4hash, f64x2hash, and f64x4hash hash small float arrays one element
+// at a time. Sharing them across types is still cheaper than a generated
+// hash function per type; see BenchmarkFloatArrayKeyMap.
+func f32x4hash(p unsafe.Pointer, h uintptr) uintptr {
+	x := (*[4]float32)(p)
+	for i := range x {
+		h = f32hash(unsafe.Pointer(&x[i]), h)
+	}
+	return h
+}
+
+func f64x2hash(p unsafe.Pointer, h uintptr) uintptr {
+	x := (*[2]float64)(p)
+	return f64hash(unsafe.Pointer(&x[1]), f64hash(unsafe.Pointer(&x[0]), h))
+}
+
+func f64x4hash(p unsafe.Pointer, h uintptr) uintptr {
+	x := (*[4]float64)(p)
+	for i := range x {
+		h = f64hash(unsafe.Pointer(&x[i]), h)
+	}
+	return h
+}
+
 func interhash(p unsafe.Pointer, h uintptr) uintptr {
 	a := (*iface)(p)
 	tab := a.tab
@@ -334,6 +362,9 @@ func memequal64(p, q unsafe.Pointer) bool {
 func memequal128(p, q unsafe.Pointer) bool {
 	return *(*[2]int64)(p) == *(*[2]int64)(q)
 }
+func memequal256(p, q unsafe.Pointer) bool {
+	return *(*[4]int64)(p) == *(*[4]int64)(q)
+}
 func f32equal(p, q unsafe.Pointer) bool {
 	return *(*float32)(p) == *(*float32)(q)
 }
@@ -346,6 +377,15 @@ func c64equal(p, q unsafe.Pointer) bool {
 func c128equal(p, q unsafe.Pointer) bool {
 	return *(*complex128)(p) == *(*complex128)(q)
 }
+func f32x4equal(p, q unsafe.Pointer) bool {
+	return *(*[4]float32)(p) == *(*[4]float32)(q)
+}
+func f64x2equal(p, q unsafe.Pointer) bool {
+	return *(*[2]float64)(p) == *(*[2]float64)(q)
+}
+func f64x4equal(p, q unsafe.Pointer) bool {
+	return *(*[4]float64)(p) == *(*[4]float64)(q)
+}
 func strequal(p, q unsafe.Pointer) bool {
 	return *(*string)(p) == *(*string)(q)
 }
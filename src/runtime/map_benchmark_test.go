@@ -431,6 +431,57 @@ func BenchmarkComplexAlgMap(b *testing.B) {
 	}
 }
 
+// BenchmarkFloatArrayKeyMap compares map lookups with small float array
+// keys, which hash and compare with shared runtime helpers, against
+// structs of the same fields, which use compiler-generated functions.
+func BenchmarkFloatArrayKeyMap(b *testing.B) {
+	b.Run("[4]float32", func(b *testing.B) {
+		m := map[[4]float32]bool{{1, 2, 3, 4}: true}
+		k := [4]float32{1, 2, 3, 4}
+		for i := 0; i < b.N; i++ {
+			_ = m[k]
+		}
+	})
+	b.Run("struct4float32", func(b *testing.B) {
+		type key struct{ a, b, c, d float32 }
+		m := map[key]bool{{1, 2, 3, 4}: true}
+		k := key{1, 2, 3, 4}
+		for i := 0; i < b.N; i++ {
+			_ = m[k]
+		}
+	})
+	b.Run("[2]float64", func(b *testing.B) {
+		m := map[[2]float64]bool{{1, 2}: true}
+		k := [2]float64{1, 2}
+		for i := 0; i < b.N; i++ {
+			_ = m[k]
+		}
+	})
+	b.Run("struct2float64", func(b *testing.B) {
+		type key struct{ a, b float64 }
+		m := map[key]bool{{1, 2}: true}
+		k := key{1, 2}
+		for i := 0; i < b.N; i++ {
+			_ = m[k]
+		}
+	})
+	b.Run("[4]float64", func(b *testing.B) {
+		m := map[[4]float64]bool{{1, 2, 3, 4}: true}
+		k := [4]float64{1, 2, 3, 4}
+		for i := 0; i < b.N; i++ {
+			_ = m[k]
+		}
+	})
+	b.Run("struct4float64", func(b *testing.B) {
+		type key struct{ a, b, c, d float64 }
+		m := map[key]bool{{1, 2, 3, 4}: true}
+		k := key{1, 2, 3, 4}
+		for i := 0; i < b.N; i++ {
+			_ = m[k]
+		}
+	})
+}
+
 func BenchmarkGoMapClear(b *testing.B) {
 	b.Run("Reflexive", func(b *testing.B) {
 		for size := 1; size < 100000; size *= 10 {
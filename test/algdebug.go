@@ -0,0 +1,33 @@
+// errorcheck -0 -d=alg
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test the -d=alg debug output describing the equality and hash
+// algorithms chosen for types.
+
+package p
+
+type Mem struct { // ERROR "alg Mem: MEM"
+	a, b int64
+}
+
+type Padded struct { // ERROR "alg Padded: SPECIAL \(padding after field a\)"
+	a int8
+	b int64
+}
+
+type Blank struct { // ERROR "alg Blank: SPECIAL \(blank field\)"
+	a int32
+	_ int32
+}
+
+type Str struct { // ERROR "alg Str: SPECIAL \(non-memory field b\)"
+	a int64
+	b string
+}
+
+func f(m1, m2 Mem, p1, p2 Padded, b1, b2 Blank, s1, s2 Str) bool {
+	return m1 == m2 && p1 == p2 && b1 == b2 && s1 == s2
+}
@@ -0,0 +1,50 @@
+// run -gcflags=-d=alghash
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that memory-comparable structs still compare and hash correctly
+// when -d=alghash forces them through generated functions.
+
+package main
+
+type P struct{ a, b int64 }
+
+type Q struct {
+	p    [3]P
+	x, y int32
+	e    struct{}
+}
+
+//go:noinline
+func eq[T comparable](x, y T) bool {
+	return x == y
+}
+
+func main() {
+	q1 := Q{x: 1}
+	q1.p[1].a = 7
+	q2 := q1
+	if !eq(q1, q2) || any(q1) != any(q2) {
+		panic("equal values compare unequal")
+	}
+
+	m := map[Q]int{q1: 1}
+	if m[q2] != 1 {
+		panic("lookup of equal key failed")
+	}
+
+	q2.p[2].b = 1
+	if eq(q1, q2) || any(q1) == any(q2) {
+		panic("unequal values compare equal")
+	}
+	if _, ok := m[q2]; ok {
+		panic("lookup of unequal key succeeded")
+	}
+
+	ms := map[struct{}]int{{}: 1}
+	if ms[struct{}{}] != 1 {
+		panic("lookup of empty struct key failed")
+	}
+}
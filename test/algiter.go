@@ -0,0 +1,25 @@
+// errorcheck
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that structs holding iterator functions are reported as
+// incomparable.
+
+package p
+
+type S struct {
+	name string
+	seq  func(yield func(int) bool)
+}
+
+func eq(a, b S) bool {
+	return a == b // ERROR "struct containing func\(yield func\(int\) bool\) cannot be compared"
+}
+
+func nilcmp(a S) bool {
+	return a.seq == nil
+}
+
+var m map[S]int // ERROR "invalid map key type S"
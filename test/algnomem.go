@@ -0,0 +1,64 @@
+// run -gcflags=-d=algnomem
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test that memory-comparable structs and arrays still compare and hash
+// correctly when -d=algnomem forces them to be compared component by
+// component.
+
+package main
+
+type P struct{ a, b int64 }
+
+type A [4]int32
+
+type Q struct {
+	p [2]P
+	b [3][2]byte
+	n uint16
+}
+
+//go:noinline
+func eq[T comparable](x, y T) bool {
+	return x == y
+}
+
+func main() {
+	a1 := A{1, 2, 3, 4}
+	a2 := a1
+	if a1 != a2 || !eq(a1, a2) || any(a1) != any(a2) {
+		panic("equal arrays compare unequal")
+	}
+	ma := map[A]int{a1: 1}
+	if ma[a2] != 1 {
+		panic("lookup of equal array key failed")
+	}
+	a2[3] = 5
+	if a1 == a2 || eq(a1, a2) || any(a1) == any(a2) {
+		panic("unequal arrays compare equal")
+	}
+	if _, ok := ma[a2]; ok {
+		panic("lookup of unequal array key succeeded")
+	}
+
+	q1 := Q{n: 1}
+	q1.p[1].a = 7
+	q1.b[2][1] = 9
+	q2 := q1
+	if q1 != q2 || !eq(q1, q2) || any(q1) != any(q2) {
+		panic("equal structs compare unequal")
+	}
+	mq := map[Q]int{q1: 1}
+	if mq[q2] != 1 {
+		panic("lookup of equal struct key failed")
+	}
+	q2.b[0][0] = 1
+	if q1 == q2 || eq(q1, q2) || any(q1) == any(q2) {
+		panic("unequal structs compare equal")
+	}
+	if _, ok := mq[q2]; ok {
+		panic("lookup of unequal struct key succeeded")
+	}
+}
@@ -0,0 +1,42 @@
+// errorcheck -0 -d=algnotes
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test the -d=algnotes diagnostics reporting comparisons and map keys
+// that need generated equality and hash functions.
+
+package p
+
+type Mem struct {
+	a, b int64
+}
+
+type Padded struct {
+	a int8
+	b int64
+}
+
+func eq(m1, m2 Mem, p1, p2 Padded) bool {
+	return m1 == m2 && p1 == p2 // ERROR "comparison of Padded needs generated equality function: padding after field a"
+}
+
+func arr(x, y [2]string) bool {
+	return x == y // ERROR "comparison of \[2\]string needs generated equality function: element type string has alg STRING"
+}
+
+func maps() (map[Mem]int, map[Padded]int, map[Padded]bool) {
+	m1 := make(map[Mem]int)
+	m2 := make(map[Padded]int)          // ERROR "map key type Padded needs generated hash function: padding after field a"
+	m3 := map[Padded]bool{{a: 1}: true} // ERROR "map key type Padded needs generated hash function: padding after field a"
+	return m1, m2, m3
+}
+
+var declared map[Padded]string // ERROR "map key type Padded needs generated hash function: padding after field a"
+
+func local() int {
+	var m map[Padded]int // ERROR "map key type Padded needs generated hash function: padding after field a"
+	var n map[Mem]int
+	return len(m) + len(n)
+}
@@ -0,0 +1,28 @@
+// errorcheck -0 -d=algpanic
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test the -d=algpanic warnings about map key types whose hashing can
+// panic because they hold interfaces.
+
+package p
+
+type Key struct {
+	id int
+	x  any
+}
+
+type Safe struct {
+	id   int
+	name string
+}
+
+func maps() (map[any]int, map[Key]int, map[Safe]int, map[[2]Key]bool) {
+	m1 := make(map[any]int) // ERROR "map key type any can panic when hashed: it holds an interface"
+	m2 := make(map[Key]int) // ERROR "map key type Key can panic when hashed: field x holds an interface"
+	m3 := make(map[Safe]int)
+	m4 := map[[2]Key]bool{} // ERROR "map key type \[2\]Key can panic when hashed: field x holds an interface"
+	return m1, m2, m3, m4
+}
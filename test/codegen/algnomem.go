@@ -0,0 +1,26 @@
+// asmcheck -gcflags=-d=algnomem
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+// Under -d=algnomem, memory-comparable arrays and structs are compared
+// component by component, never with memequal or merged loads.
+
+type bytes8 [8]byte
+
+type ints5 struct{ a, b, c, d, e int64 }
+
+func eqBytes8(a, b *bytes8) bool {
+	// amd64:"CMPB",-"CMPQ",-".*memequal"
+	// arm64:-".*memequal"
+	return *a == *b
+}
+
+func eqInts5(a, b *ints5) bool {
+	// amd64:`.*type:\.eq\.`,-".*memequal"
+	// arm64:`.*type:\.eq\.`,-".*memequal"
+	return *a == *b
+}
@@ -0,0 +1,27 @@
+// asmcheck
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codegen
+
+// Comparisons of struct literals with constant fields are folded to
+// constants, so they never call memequal.
+
+type constStr struct {
+	s string
+	n int
+}
+
+func constStructEqual() bool {
+	// amd64:-"CALL"
+	// arm64:-"CALL"
+	return constStr{"hello, world, hello!", 1} == constStr{"hello, world, hello?", 1}
+}
+
+func constStructNotEqual() bool {
+	// amd64:-"CALL"
+	// arm64:-"CALL"
+	return constStr{"hello, world, hello!", 1} != constStr{"hello, world, hello!", 2}
+}
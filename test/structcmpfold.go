@@ -0,0 +1,72 @@
+// run
+
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test comparisons of struct literals. The compiler folds a comparison
+// to a constant only when both literals set every field to a constant;
+// literals with omitted, variable, or call fields, or with nested struct
+// fields such as Nested's, are compared at run time, and both paths must
+// agree.
+
+package main
+
+type Point struct {
+	X, Y int
+}
+
+type Mixed struct {
+	b bool
+	f float64
+	s string
+	_ int
+	c complex128
+}
+
+type Nested struct {
+	p Point
+	n int
+}
+
+type Blank struct {
+	_ int
+	x int
+}
+
+var one = 1
+
+var calls int
+
+func f() int {
+	calls++
+	return 1
+}
+
+func main() {
+	check(Point{1, 2} == Point{1, 2}, true)
+	check(Point{1, 2} == Point{1, 3}, false)
+	check(Point{1, 2} != Point{1, 3}, true)
+	check(Point{X: 1, Y: 2} == Point{Y: 2, X: 1}, true)
+	check(Point{X: 1} == Point{1, 0}, true)
+	check(Point{one, 2} == Point{1, 2}, true)
+	check(Mixed{true, 0.5, "a", 0, 1i} == Mixed{true, 0.5, "a", 0, 1i}, true)
+	check(Mixed{true, 0.5, "a", 0, 1i} == Mixed{true, 0.5, "b", 0, 1i}, false)
+	check(Mixed{f: 0} == Mixed{f: -0.0}, true)
+	check(Nested{Point{1, 2}, 3} == Nested{Point{1, 2}, 3}, true)
+	check(Nested{Point{1, 2}, 3} == Nested{Point{2, 2}, 3}, false)
+	check(struct{ a, b int8 }{1, 2} == struct{ a, b int8 }{1, 2}, true)
+
+	// Blank fields are not compared, but their operands must still
+	// be evaluated.
+	check(Blank{f(), 1} == Blank{f(), 1}, true)
+	if calls != 2 {
+		panic("operands of blank fields not evaluated")
+	}
+}
+
+func check(got, want bool) {
+	if got != want {
+		panic("bad struct comparison")
+	}
+}